@@ -1,9 +1,12 @@
 package gokoa
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -24,10 +27,22 @@ type Middleware func(ctx *Context, next func() error) error
 // registered into the Application.
 type composedHandler func(ctx *Context) error
 
+// A JSONEncoder is a function, which encodes the given value into
+// JSON when it is assigned to the HTTP response body.
+type JSONEncoder func(v interface{}) ([]byte, error)
+
 // An ErrorHandler is a function, which is responsible for handling
 // error returned by any middleware.
 type ErrorHandler func(err error)
 
+// An ErrorRenderer is a function, which renders the given HttpError
+// into the HTTP response status code and body, when any middleware
+// returns an error.
+//
+// Headers set before the error, except the ones in the HttpError, are
+// removed before the ErrorRenderer is called.
+type ErrorRenderer func(ctx *Context, err *HttpError)
+
 // An Application represents a HTTP web server, which handle HTTP
 // requests by processing HTTP responses.
 type Application struct {
@@ -47,12 +62,25 @@ type Application struct {
 	// verify client cookies, default to an empty array.
 	Keys []string
 
+	// Keygrip signs and verifies client cookies, which enables key
+	// rotation and choosing the HMAC algorithm, default to nil, which
+	// means a Keygrip with Keys using HMAC-SHA1.
+	Keygrip *Keygrip
+
 	// Proxy is equal to true when fields in the proxy header will be
 	// trusted, default to false.
 	Proxy bool
 
-	// SubdomainOffset is the offset of subdomain to be ignored, default
-	// to 0 (means no ignoring).
+	// JSONEncoder is the function encoding values into JSON for HTTP
+	// response bodies, default to json.Marshal.
+	JSONEncoder JSONEncoder
+
+	// ErrorRenderer is the function rendering errors returned by
+	// middlewares into HTTP responses, default to RenderError.
+	ErrorRenderer ErrorRenderer
+
+	// SubdomainOffset is the number of the rightmost host labels to be
+	// ignored when accessing subdomains, default to 2.
 	SubdomainOffset int
 
 	// proxyIpHeader is a key in the proxy header, defaulting to
@@ -64,12 +92,22 @@ type Application struct {
 	// maxIpsCount is the maximum of ip addresses read from the proxy
 	// header, default to 0 (means infinity).
 	maxIpsCount int
+
+	// trustedProxies are networks of proxies whose proxy headers will
+	// be trusted when Proxy is equal to true, default to nil (means
+	// all proxies are trusted).
+	trustedProxies []*net.IPNet
 }
 
 var (
 	// defaultErrorHandler is the default error handler for the
-	// Application.
+	// Application, which ignores errors rendered as exposed or 404
+	// HttpErrors, e.g. a *ValidationError.
 	defaultErrorHandler = func(err error) {
+		if httpErr := toHttpError(err); httpErr.Expose || httpErr.Status == http.StatusNotFound {
+			return
+		}
+
 		log.Println()
 		log.Println("gokoa: ", err)
 		log.Println()
@@ -107,12 +145,28 @@ func NewApplication(config ApplicationConfig) *Application {
 		app.Keys = keys.([]string)
 	}
 
+	if keygrip, ok := config["keygrip"]; ok {
+		app.Keygrip = keygrip.(*Keygrip)
+	}
+
 	if proxy, ok := config["proxy"]; ok {
 		app.Proxy = proxy.(bool)
 	} else {
 		app.Proxy = false
 	}
 
+	if jsonEncoder, ok := config["jsonEncoder"]; ok {
+		app.JSONEncoder = jsonEncoder.(JSONEncoder)
+	} else {
+		app.JSONEncoder = json.Marshal
+	}
+
+	if errorRenderer, ok := config["errorRenderer"]; ok {
+		app.ErrorRenderer = errorRenderer.(ErrorRenderer)
+	} else {
+		app.ErrorRenderer = RenderError
+	}
+
 	if subdomainOffset, ok := config["subdomainOffset"]; ok {
 		app.SubdomainOffset = subdomainOffset.(int)
 	} else {
@@ -131,9 +185,70 @@ func NewApplication(config ApplicationConfig) *Application {
 		app.maxIpsCount = 0
 	}
 
+	if trustedProxies, ok := config["trustedProxies"]; ok {
+		for _, proxy := range trustedProxies.([]string) {
+			app.trustedProxies = append(app.trustedProxies, parseNetwork(proxy))
+		}
+	}
+
 	return app
 }
 
+// IndentJSONEncoder returns a JSONEncoder, which encodes values into
+// indented JSON by json.MarshalIndent with the given prefix and indent.
+//
+// It is useful for debugging in the development environment:
+//
+//	if app.Env == "development" {
+//		app.JSONEncoder = gokoa.IndentJSONEncoder("", "  ")
+//	}
+func IndentJSONEncoder(prefix string, indent string) JSONEncoder {
+	return func(v interface{}) ([]byte, error) {
+		return json.MarshalIndent(v, prefix, indent)
+	}
+}
+
+// parseNetwork parses the given string as either a CIDR or a single
+// IP address, and returns the corresponding network.
+//
+// parseNetwork panics when the given string is neither of them.
+func parseNetwork(s string) *net.IPNet {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		panic(fmt.Sprintf("gokoa: invalid trusted proxy %q", s))
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// isProxyTrusted returns true when proxy headers sent from the given
+// IP address should be trusted.
+func (app *Application) isProxyTrusted(ip string) bool {
+	if !app.Proxy {
+		return false
+	}
+	if len(app.trustedProxies) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range app.trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
 // Listen causes the Application to create a new HTTP server with a
 // single handler which composes all middlewares registered in, and
 // listen on the given TCP port for incoming connections.
@@ -169,10 +284,14 @@ func (app *Application) Callback() func(res http.ResponseWriter, req *http.Reque
 	}
 }
 
-// compose composes all middlewares in the Application into a single
-// composedHandler and returns it.
-func compose(middlewares []Middleware) composedHandler {
-	return func(ctx *Context) error {
+// Compose composes the given middlewares into a single Middleware,
+// which executes them in order and calls next after the last one
+// calls its own next.
+//
+// next can be nil, which means that there is nothing to be executed
+// after the composed middlewares.
+func Compose(middlewares ...Middleware) Middleware {
+	return func(ctx *Context, next func() error) error {
 		index := -1
 		var dispatch func(int) error
 		dispatch = func(i int) error {
@@ -182,7 +301,10 @@ func compose(middlewares []Middleware) composedHandler {
 			}
 			index = i
 			if i == len(middlewares) {
-				return nil
+				if next == nil {
+					return nil
+				}
+				return next()
 			}
 			middleware := middlewares[i]
 			return middleware(ctx, func() error {
@@ -193,6 +315,15 @@ func compose(middlewares []Middleware) composedHandler {
 	}
 }
 
+// compose composes all middlewares in the Application into a single
+// composedHandler and returns it.
+func compose(middlewares []Middleware) composedHandler {
+	middleware := Compose(middlewares...)
+	return func(ctx *Context) error {
+		return middleware(ctx, nil)
+	}
+}
+
 // createContext creates a new Context, a new Request and a new
 // Response and binds them together.
 //
@@ -205,9 +336,14 @@ func (app *Application) createContext(res http.ResponseWriter, req *http.Request
 	request.Req = req
 	request.app = app
 	request.ctx = ctx
+	if req.RequestURI != "" {
+		request.originalURL = req.RequestURI
+	} else {
+		request.originalURL = req.URL.RequestURI()
+	}
 
 	response := NewResponse()
-	response.Res = res
+	response.Res = newResponseWriter(res)
 	response.app = app
 	response.ctx = ctx
 
@@ -222,9 +358,12 @@ func (app *Application) createContext(res http.ResponseWriter, req *http.Request
 
 // handleRequest is responsible for handling HTTP request.
 func (app *Application) handleRequest(ctx *Context, handler composedHandler) {
+	defer ctx.finish()
+
 	err := handler(ctx)
 	if err != nil {
 		app.errorHandler(err)
+		app.respondError(ctx, err)
 		return
 	}
 
@@ -234,8 +373,23 @@ func (app *Application) handleRequest(ctx *Context, handler composedHandler) {
 // respond is responsible for processing HTTP response and sending it
 // to the client.
 func (app *Application) respond(ctx *Context) {
+	defer func() {
+		ctx.Response.finished = true
+	}()
+
+	if !ctx.Response.Writable() {
+		return
+	}
+
 	statusCode := ctx.Response.GetStatus()
 	body := ctx.Response.GetBody()
+	stream := ctx.Response.GetStream()
+
+	// the HTTP response header has been flushed by FlushHeaders, and
+	// the HTTP response body may have been written directly
+	if ctx.Response.HeaderSent() && body == nil && stream == nil {
+		return
+	}
 
 	ctx.Response.Res.WriteHeader(statusCode)
 
@@ -247,10 +401,14 @@ func (app *Application) respond(ctx *Context) {
 
 	// HTTP HEAD request
 	if ctx.Request.GetMethod() == "HEAD" {
-		if ctx.Response.GetLength() != 0 {
-			ctx.Response.SetBody(ctx.Response.GetBody()[0:ctx.Response.GetLength()])
+		return
+	}
+
+	// streaming response body
+	if stream != nil {
+		if _, err := io.Copy(ctx.Response.Res, stream); err != nil {
+			app.errorHandler(err)
 		}
-		ctx.Response.Res.Write(body)
 		return
 	}
 
@@ -262,12 +420,55 @@ func (app *Application) respond(ctx *Context) {
 	ctx.Response.Res.Write(body)
 }
 
+// respondError sends back the given error returned by middlewares,
+// which is rendered by the ErrorRenderer.
+//
+// Nothing is sent when the HTTP response header has been sent, since
+// it is too late to send the error.
+func (app *Application) respondError(ctx *Context, err error) {
+	if ctx.Response.HeaderSent() || !ctx.Response.Writable() {
+		return
+	}
+
+	httpErr := toHttpError(err)
+
+	header := ctx.Response.Res.Header()
+	for field := range header {
+		header.Del(field)
+	}
+	for field, value := range httpErr.Headers {
+		ctx.Response.Set(field, value)
+	}
+
+	// discard the half-built response, while a stream is still closed
+	// through OnFinished
+	ctx.Response.body = nil
+	ctx.Response.stream = nil
+	ctx.Response.fileInfo = nil
+	ctx.Response.SetStatus(httpErr.Status)
+
+	app.ErrorRenderer(ctx, httpErr)
+	app.respond(ctx)
+}
+
 func isStatusEmpty(statusCode int) bool {
 	return statusCode == http.StatusNoContent ||
 		statusCode == http.StatusResetContent ||
 		statusCode == http.StatusNotModified
 }
 
+// keygrip returns the Keygrip signing and verifying client cookies,
+// or nil when there is neither Keygrip nor Keys.
+func (app *Application) keygrip() *Keygrip {
+	if app.Keygrip != nil {
+		return app.Keygrip
+	}
+	if len(app.Keys) == 0 {
+		return nil
+	}
+	return NewKeygrip(app.Keys, "")
+}
+
 // Use registers the given middleware into the Application.
 //
 // Use returns the Application itself, which enables chained function
@@ -278,6 +479,35 @@ func (app *Application) Use(middleware Middleware) *Application {
 	return app
 }
 
+// Middleware returns a Middleware composing all middlewares registered
+// into the Application, which enables the Application to be mounted
+// into another Application, e.g. by host or by path.
+//
+// While the middlewares of the mounted Application are executed, the
+// Context belongs to the mounted Application, so that its settings
+// like Keys, Proxy, SubdomainOffset and JSONEncoder take effect, and
+// the Context belongs to the outer Application again when next is
+// called or the middlewares return. However, an error returned by the
+// middlewares is handled and rendered by the outer Application.
+//
+// Middlewares registered into the Application after calling
+// Middleware are NOT included.
+func (app *Application) Middleware() Middleware {
+	middleware := Compose(app.middlewares...)
+
+	return func(ctx *Context, next func() error) error {
+		parent := ctx.app
+		ctx.setApp(app)
+		defer ctx.setApp(parent)
+
+		return middleware(ctx, func() error {
+			ctx.setApp(parent)
+			defer ctx.setApp(app)
+			return next()
+		})
+	}
+}
+
 // OnError registers a new ErrorHandler into the Application.
 func (app *Application) OnError(handler ErrorHandler) {
 	app.errorHandler = handler
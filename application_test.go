@@ -3,11 +3,15 @@ package gokoa
 import (
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -25,9 +29,11 @@ func TestNewApplication_WithoutConfig(t *testing.T) {
 	assert.Equal(t, "development", app.Env)
 	assert.Equal(t, []string(nil), app.Keys)
 	assert.Equal(t, false, app.Proxy)
+	assert.NotNil(t, app.JSONEncoder)
 	assert.Equal(t, 2, app.SubdomainOffset)
 	assert.Equal(t, "X-Forwarded-For", app.proxyIpHeader)
 	assert.Equal(t, 0, app.maxIpsCount)
+	assert.Equal(t, []*net.IPNet(nil), app.trustedProxies)
 
 	// arrange
 	os.Setenv("GOKOA_ENV", "test")
@@ -48,9 +54,13 @@ func TestNewApplication_WithConfig(t *testing.T) {
 	config["env"] = "test"
 	config["keys"] = []string{ "1", "2", "3" }
 	config["proxy"] = true
+	config["jsonEncoder"] = JSONEncoder(func(v interface{}) ([]byte, error) {
+		return []byte("encoded"), nil
+	})
 	config["subdomainOffset"] = 5
 	config["proxyIpHeader"] = ""
 	config["maxIpsCount"] = 10
+	config["trustedProxies"] = []string{ "10.0.0.0/8", "127.0.0.1" }
 
 	// act
 	app = NewApplication(config)
@@ -59,9 +69,23 @@ func TestNewApplication_WithConfig(t *testing.T) {
 	assert.Equal(t, "test", app.Env)
 	assert.Equal(t, []string{ "1", "2", "3" }, app.Keys)
 	assert.Equal(t, true, app.Proxy)
+	encoded, err := app.JSONEncoder(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("encoded"), encoded)
 	assert.Equal(t, 5, app.SubdomainOffset)
 	assert.Equal(t, "", app.proxyIpHeader)
 	assert.Equal(t, 10, app.maxIpsCount)
+	assert.Equal(t, []*net.IPNet{
+		{ IP: net.IP{ 10, 0, 0, 0 }, Mask: net.CIDRMask(8, 32) },
+		{ IP: net.IP{ 127, 0, 0, 1 }, Mask: net.CIDRMask(32, 32) },
+	}, app.trustedProxies)
+}
+
+func TestNewApplication_InvalidTrustedProxy(t *testing.T) {
+	// assert
+	assert.Panics(t, func() {
+		NewApplication(ApplicationConfig{ "trustedProxies": []string{ "invalid" } })
+	})
 }
 
 func TestApplication_Listen(t *testing.T) {
@@ -326,3 +350,246 @@ func TestApplication_OnError(t *testing.T) {
 	assert.Equal(t, true, called)
 	assert.Equal(t, "error message", errorMessage)
 }
+
+func TestCompose(t *testing.T) {
+	var ctx *Context
+	var calls []int
+	var err error
+
+	// arrange
+	calls = nil
+	ctx = NewContext()
+	ctx.Response = NewResponse()
+	middleware := Compose(
+		func(ctx *Context, next func() error) error {
+			calls = append(calls, 1)
+			err := next()
+			calls = append(calls, 4)
+			return err
+		},
+		func(ctx *Context, next func() error) error {
+			calls = append(calls, 2)
+			return next()
+		},
+	)
+
+	// act
+	err = middleware(ctx, func() error {
+		calls = append(calls, 3)
+		return nil
+	})
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4}, calls)
+
+	// arrange
+	calls = nil
+
+	// act
+	err = middleware(ctx, nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 4}, calls)
+}
+
+func TestApplication_Middleware(t *testing.T) {
+	var app *Application
+	var mounted *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var res *http.Response
+	var body []byte
+	var err error
+
+	// arrange
+	mounted = NewApplication(nil)
+	mounted.Use(func(ctx *Context, next func() error) error {
+		ctx.SetBody("mounted")
+		return next()
+	})
+	app = NewApplication(nil)
+	app.Use(mounted.Middleware()).Use(func(ctx *Context, next func() error) error {
+		ctx.SetBody(string(ctx.GetBody()) + " app")
+		return nil
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+	res = rec.Result()
+	body, err = ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	// assert
+	assert.Equal(t, []byte("mounted app"), body)
+}
+
+func TestApplication_Middleware_Keys(t *testing.T) {
+	var app *Application
+	var mounted *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+
+	// arrange
+	mounted = NewApplication(ApplicationConfig{ "keys": []string{ "mounted key" } })
+	mounted.Use(func(ctx *Context, next func() error) error {
+		ctx.Cookies.Set("mounted", "gokoa", nil)
+		if err := next(); err != nil {
+			return err
+		}
+		ctx.Cookies.Set("after", "gokoa", nil)
+		return nil
+	})
+	app = NewApplication(ApplicationConfig{ "keys": []string{ "app key" } })
+	app.Use(mounted.Middleware()).Use(func(ctx *Context, next func() error) error {
+		ctx.Cookies.Set("app", "gokoa", nil)
+		ctx.SetStatus(http.StatusOK)
+		return nil
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, []string{
+		"mounted=gokoa; Path=/; HttpOnly",
+		"mounted.sig=" + NewKeygrip([]string{ "mounted key" }, "").Sign("mounted=gokoa") + "; Path=/; HttpOnly",
+		"app=gokoa; Path=/; HttpOnly",
+		"app.sig=" + NewKeygrip([]string{ "app key" }, "").Sign("app=gokoa") + "; Path=/; HttpOnly",
+		"after=gokoa; Path=/; HttpOnly",
+		"after.sig=" + NewKeygrip([]string{ "mounted key" }, "").Sign("after=gokoa") + "; Path=/; HttpOnly",
+	}, rec.Header()["Set-Cookie"])
+}
+
+// closeRecorder is an io.Reader recording whether it is closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (reader *closeRecorder) Close() error {
+	reader.closed = true
+	return nil
+}
+
+func TestApplication_Callback_Stream(t *testing.T) {
+	var app *Application
+	var stream *closeRecorder
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var res *http.Response
+	var body []byte
+	var err error
+
+	for _, method := range []string{ http.MethodGet, http.MethodHead } {
+		// arrange
+		stream = &closeRecorder{ Reader: strings.NewReader("hello gokoa") }
+		app = NewApplication(nil)
+		app.Use(func(ctx *Context, next func() error) error {
+			ctx.SetBody(stream)
+			return nil
+		})
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(method, "/", nil)
+
+		// act
+		app.Callback()(rec, req)
+		res = rec.Result()
+		body, err = ioutil.ReadAll(res.Body)
+		assert.Nil(t, err)
+		defer res.Body.Close()
+
+		// assert
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "", res.Header.Get("Content-Length"))
+		assert.Equal(t, true, stream.closed)
+		if method == http.MethodGet {
+			assert.Equal(t, []byte("hello gokoa"), body)
+		} else {
+			assert.Empty(t, body)
+		}
+	}
+}
+
+func TestApplication_Callback_FlushHeaders(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var headerSent bool
+
+	// arrange
+	app = NewApplication(nil)
+	app.Use(func(ctx *Context, next func() error) error {
+		ctx.SetStatus(http.StatusOK)
+		ctx.Response.Set("Content-Type", "text/event-stream")
+		ctx.Response.FlushHeaders()
+		headerSent = ctx.Response.HeaderSent()
+		ctx.Response.Res.Write([]byte("data: hello\n\n"))
+		return nil
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.True(t, headerSent)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "data: hello\n\n", rec.Body.String())
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	var output strings.Builder
+
+	// arrange
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	// act
+	defaultErrorHandler(&ValidationError{ Fields: []FieldError{ { Field: "name", Rule: "required" } } })
+	defaultErrorHandler(&BindError{ Source: "body", Err: errors.New("unexpected EOF") })
+	defaultErrorHandler(NewHttpError(http.StatusNotFound, "", nil))
+
+	// assert
+	assert.Equal(t, "", output.String())
+
+	// act
+	defaultErrorHandler(errors.New("database is down"))
+
+	// assert
+	assert.Contains(t, output.String(), "gokoa:  database is down")
+}
+
+func TestApplication_Callback_BindingErrorNotLogged(t *testing.T) {
+	var output strings.Builder
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+
+	// arrange
+	app = NewApplication(nil)
+	app.Use(func(ctx *Context, next func() error) error {
+		var target bindingTarget
+		return ctx.Bind(&target)
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "", output.String())
+}
@@ -0,0 +1,257 @@
+package gokoa
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultBindLimit is the maximum of bytes in the request body read by
+// Context.Bind, when the body is NOT parsed yet.
+const defaultBindLimit = 1 << 20
+
+// A BindError is returned when a request can NOT be decoded into the
+// destination, which will be rendered as 400 Bad Request.
+type BindError struct {
+	// Source is where values are decoded from, which is one of "body",
+	// "query" and "params".
+	Source string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the message of the BindError.
+func (err *BindError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", err.Source, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *BindError) Unwrap() error {
+	return err.Err
+}
+
+// Bind decodes the request body into the given pointer to struct, and
+// validates it by Validate.
+//
+// A JSON body is decoded by encoding/json, while an URL encoded form
+// body is decoded by "form" tags of fields. A JSON body is always
+// decoded from the raw body, which is read from the connection unless
+// it is read already, e.g. by a body parser, while any other parsed
+// body takes precedence over reading the body from the connection.
+//
+// Bind returns a *BindError when the body can NOT be decoded, or a
+// *ValidationError when the decoded values violate validation rules.
+func (ctx *Context) Bind(dst interface{}) error {
+	body := ctx.Request.GetBody()
+	raw := ctx.Request.GetRawBody()
+
+	// a JSON body is decoded from the raw body straight into dst, so that
+	// numbers do NOT lose precision as float64 in between
+	if _, ok := ctx.Request.Is("json", "+json"); ok && (raw != nil || body == nil && ctx.Request.HasBody()) {
+		limit := defaultBindLimit
+		if raw != nil {
+			limit = 0
+		}
+		if err := ctx.Request.ReadJSON(dst, limit); err != nil {
+			return &BindError{Source: "body", Err: err}
+		}
+		return Validate(dst)
+	}
+
+	if body == nil && ctx.Request.HasBody() {
+		if _, ok := ctx.Request.Is("urlencoded"); ok {
+			form, err := ctx.Request.ReadForm(defaultBindLimit)
+			if err != nil {
+				return &BindError{Source: "body", Err: err}
+			}
+			body = form
+		} else {
+			return &BindError{Source: "body", Err: fmt.Errorf("unsupported content type %q", ctx.Request.GetType())}
+		}
+	}
+
+	switch body := body.(type) {
+	case nil:
+	case url.Values:
+		if err := decodeValues(body, dst, "form"); err != nil {
+			return &BindError{Source: "body", Err: err}
+		}
+	case string:
+		return &BindError{Source: "body", Err: fmt.Errorf("can not bind text")}
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return &BindError{Source: "body", Err: err}
+		}
+		if err := json.Unmarshal(encoded, dst); err != nil {
+			return &BindError{Source: "body", Err: err}
+		}
+	}
+
+	return Validate(dst)
+}
+
+// BindQuery decodes the query string of the request URL into the given
+// pointer to struct by "query" tags of fields, and validates it by
+// Validate.
+//
+// BindQuery returns the same errors as Bind.
+func (ctx *Context) BindQuery(dst interface{}) error {
+	if err := decodeValues(ctx.Request.GetQuery(), dst, "query"); err != nil {
+		return &BindError{Source: "query", Err: err}
+	}
+	return Validate(dst)
+}
+
+// BindParams decodes Params into the given pointer to struct by
+// "param" tags of fields, and validates it by Validate.
+//
+// BindParams returns the same errors as Bind.
+func (ctx *Context) BindParams(dst interface{}) error {
+	values := make(url.Values, len(ctx.Params))
+	for key, value := range ctx.Params {
+		values.Set(key, value)
+	}
+	if err := decodeValues(values, dst, "param"); err != nil {
+		return &BindError{Source: "params", Err: err}
+	}
+	return Validate(dst)
+}
+
+// bindingHttpError returns the HttpError corresponding to the given
+// error when it is a *BindError or a *ValidationError, which is
+// rendered as 400 Bad Request or 422 Unprocessable Entity with
+// violated rules respectively, and true, otherwise returns false.
+func bindingHttpError(err error) (*HttpError, bool) {
+	var bindErr *BindError
+	var validationErr *ValidationError
+
+	switch {
+	case errors.As(err, &bindErr):
+		return NewHttpError(http.StatusBadRequest, bindErr.Error(), nil), true
+	case errors.As(err, &validationErr):
+		return NewHttpError(http.StatusUnprocessableEntity, "validation failed", map[string]interface{}{
+			"errors": validationErr.Fields,
+		}), true
+	default:
+		return nil, false
+	}
+}
+
+// decodeValues decodes the given values into the given pointer to
+// struct, where fields are named by the given tag, or by their names
+// when the tag is absent.
+//
+// Strings, booleans, numbers, encoding.TextUnmarshalers, pointers to
+// them and slices of them are supported.
+func decodeValues(values url.Values, dst interface{}, tag string) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.New("destination must be a non-nil pointer to struct")
+	}
+	return decodeStruct(values, value.Elem(), tag)
+}
+
+// decodeStruct decodes the given values into fields of the given
+// struct value.
+func decodeStruct(values url.Values, value reflect.Value, tag string) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if field.Anonymous && fieldValue.Kind() == reflect.Struct {
+			if err := decodeStruct(values, fieldValue, tag); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := fieldName(field, tag)
+		if name == "-" {
+			continue
+		}
+		strs, ok := values[name]
+		if !ok || len(strs) == 0 {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Slice && !implementsTextUnmarshaler(fieldValue) {
+			slice := reflect.MakeSlice(fieldValue.Type(), len(strs), len(strs))
+			for j, str := range strs {
+				if err := decodeString(str, slice.Index(j)); err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+			}
+			fieldValue.Set(slice)
+			continue
+		}
+
+		if err := decodeString(strs[0], fieldValue); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// implementsTextUnmarshaler returns true when a pointer to the given
+// value implements encoding.TextUnmarshaler.
+func implementsTextUnmarshaler(value reflect.Value) bool {
+	return value.CanAddr() && value.Addr().Type().Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+// decodeString decodes the given string into the given value.
+func decodeString(str string, value reflect.Value) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return decodeString(str, value.Elem())
+	}
+
+	if implementsTextUnmarshaler(value) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(str))
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(str), 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(str), 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindingTarget struct {
+	Name     string        `json:"name" form:"name" query:"name" param:"name" validate:"required"`
+	Age      int           `json:"age" form:"age" query:"age" param:"age"`
+	Tags     []string      `json:"tags" form:"tags" query:"tags"`
+	Admin    *bool         `json:"admin" form:"admin" query:"admin"`
+	Timeout  time.Duration `json:"-" form:"-" query:"-"`
+	JoinedAt time.Time     `json:"joinedAt" form:"joinedAt" query:"joinedAt"`
+}
+
+func TestDecodeValues(t *testing.T) {
+	var target bindingTarget
+	var err error
+
+	// act
+	err = decodeValues(map[string][]string{
+		"name":     { "gokoa" },
+		"age":      { "18" },
+		"tags":     { "koa", "go" },
+		"admin":    { "true" },
+		"joinedAt": { "2020-06-01T00:00:00Z" },
+	}, &target, "query")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "gokoa", target.Name)
+	assert.Equal(t, 18, target.Age)
+	assert.Equal(t, []string{ "koa", "go" }, target.Tags)
+	assert.Equal(t, true, *target.Admin)
+	assert.Equal(t, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), target.JoinedAt)
+
+	// act
+	err = decodeValues(map[string][]string{ "age": { "old" } }, &target, "query")
+
+	// assert
+	assert.NotNil(t, err)
+
+	// act
+	err = decodeValues(nil, target, "query")
+
+	// assert
+	assert.NotNil(t, err)
+}
+
+func TestContext_Bind(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var res *http.Response
+	var body []byte
+	var target bindingTarget
+	var err error
+
+	// arrange
+	app = NewApplication(nil)
+	app.Use(func(ctx *Context, next func() error) error {
+		target = bindingTarget{}
+		if err := ctx.Bind(&target); err != nil {
+			return err
+		}
+		ctx.SetBody(target.Name)
+		return nil
+	})
+
+	for _, contentType := range []string{ "application/json", "application/x-www-form-urlencoded" } {
+		// arrange
+		rec = httptest.NewRecorder()
+		if contentType == "application/json" {
+			req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"gokoa","age":18}`))
+		} else {
+			req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`name=gokoa&age=18`))
+		}
+		req.Header.Set("Content-Type", contentType)
+
+		// act
+		app.Callback()(rec, req)
+		res = rec.Result()
+
+		// assert
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 18, target.Age)
+	}
+
+	// arrange
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+
+	// act
+	app.OnError(func(error) {})
+	app.Callback()(rec, req)
+	res = rec.Result()
+
+	// assert
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// arrange
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age":18}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	// act
+	app.Callback()(rec, req)
+	res = rec.Result()
+	body, err = ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	// assert
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	assert.JSONEq(t, `{"message":"validation failed","errors":[{"field":"name","rule":"required","message":"name is required"}]}`, string(body))
+
+	// arrange
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<user/>`))
+	req.Header.Set("Content-Type", "application/xml")
+
+	// act
+	app.Callback()(rec, req)
+	res = rec.Result()
+
+	// assert
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestContext_Bind_LargeInt(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var target struct {
+		ID int64 `json:"id"`
+	}
+
+	for _, parsed := range []bool{ false, true } {
+		// arrange
+		parsed := parsed
+		app = NewApplication(nil)
+		app.Use(func(ctx *Context, next func() error) error {
+			if parsed {
+				// mimic a body parser, which has read the raw body
+				if _, err := ctx.Request.ReadBody(0); err != nil {
+					return err
+				}
+				ctx.Request.SetBody(map[string]interface{}{ "id": float64(9007199254740993) })
+			}
+			target.ID = 0
+			if err := ctx.Bind(&target); err != nil {
+				return err
+			}
+			ctx.SetStatus(http.StatusOK)
+			return nil
+		})
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":9007199254740993}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		// act
+		app.Callback()(rec, req)
+
+		// assert
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, int64(9007199254740993), target.ID)
+	}
+}
+
+func TestContext_BindQuery(t *testing.T) {
+	var ctx *Context
+	var target bindingTarget
+	var err error
+
+	// arrange
+	ctx = NewContext()
+	ctx.Request = NewRequest()
+	ctx.Request.Req = httptest.NewRequest(http.MethodGet, "/?name=gokoa&tags=a&tags=b", nil)
+
+	// act
+	err = ctx.BindQuery(&target)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "gokoa", target.Name)
+	assert.Equal(t, []string{ "a", "b" }, target.Tags)
+
+	// arrange
+	ctx.Request.Req = httptest.NewRequest(http.MethodGet, "/?age=x", nil)
+
+	// act
+	err = ctx.BindQuery(&target)
+
+	// assert
+	assert.IsType(t, &BindError{}, err)
+}
+
+func TestContext_BindParams(t *testing.T) {
+	var ctx *Context
+	var target bindingTarget
+	var err error
+
+	// arrange
+	ctx = NewContext()
+	ctx.Params["age"] = "18"
+
+	// act
+	err = ctx.BindParams(&target)
+
+	// assert
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, 18, target.Age)
+}
@@ -0,0 +1,190 @@
+// Package bodyparser provides a middleware for gokoa, which parses
+// HTTP request bodies according to the Content-Type header.
+//
+//	app.Use(bodyparser.New(nil))
+//
+//	app.Use(func(ctx *gokoa.Context, next func() error) error {
+//		// map[string]interface{} for a JSON object
+//		body := ctx.Request.GetBody()
+//		// the bytes read from the connection
+//		raw := ctx.Request.GetRawBody()
+//		...
+//	})
+package bodyparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// A Config is a container which stores settings for configuring the
+// body parser.
+//
+// The Config is organized as key-value pairs, where the value is of
+// limited type.
+type Config map[string]interface{}
+
+// A parser parses the request body of a specific type.
+type parser struct {
+	// types are types of request bodies to be parsed, which are
+	// matched by Request.Is.
+	types []string
+
+	// limit is the maximum of bytes in the request body.
+	limit int
+
+	// parse parses the request body.
+	parse func(request *gokoa.Request, limit int) (interface{}, error)
+}
+
+// errNotStrict is returned when a JSON request body is neither an
+// object nor an array in the strict mode.
+var errNotStrict = errors.New("invalid JSON, only supports object and array")
+
+// New returns a Middleware, which parses the request body by the
+// Content-Type header before calling next, and stores the result by
+// Request.SetBody.
+//
+// The following request bodies are parsed:
+//
+//	json   JSON, e.g. application/json, parsed into an interface{}
+//	form   URL encoded form, parsed into an url.Values
+//	text   plain text, parsed into a string
+//
+// The config can be nil, which causes the body parser to use default
+// configuration settings:
+//
+//	enableTypes  []string  types to be parsed, default to ["json", "form"]
+//	jsonLimit    int       maximum of bytes in a JSON body, default to 1 MiB
+//	formLimit    int       maximum of bytes in a form body, default to 56 KiB
+//	textLimit    int       maximum of bytes in a text body, default to 1 MiB
+//	strict       bool      only accept JSON objects and arrays, default to true
+//
+// The returned Middleware responds with 413 Payload Too Large when a
+// request body is larger than the limit, 415 Unsupported Media Type
+// when its charset is NOT supported, and 400 Bad Request when it is
+// malformed, without calling next.
+//
+// Values in key-value pairs must be in the valid type, otherwise New
+// will panic.
+func New(config Config) gokoa.Middleware {
+	if config == nil {
+		config = make(Config)
+	}
+
+	enableTypes := []string{"json", "form"}
+	if types, ok := config["enableTypes"]; ok {
+		enableTypes = types.([]string)
+	}
+
+	jsonLimit := 1 << 20
+	if limit, ok := config["jsonLimit"]; ok {
+		jsonLimit = limit.(int)
+	}
+
+	formLimit := 56 << 10
+	if limit, ok := config["formLimit"]; ok {
+		formLimit = limit.(int)
+	}
+
+	textLimit := 1 << 20
+	if limit, ok := config["textLimit"]; ok {
+		textLimit = limit.(int)
+	}
+
+	strict := true
+	if s, ok := config["strict"]; ok {
+		strict = s.(bool)
+	}
+
+	var parsers []parser
+	for _, typ := range enableTypes {
+		switch typ {
+		case "json":
+			parsers = append(parsers, parser{
+				types: []string{"json", "+json", "application/csp-report"},
+				limit: jsonLimit,
+				parse: func(request *gokoa.Request, limit int) (interface{}, error) {
+					return parseJSON(request, limit, strict)
+				},
+			})
+		case "form":
+			parsers = append(parsers, parser{
+				types: []string{"urlencoded"},
+				limit: formLimit,
+				parse: func(request *gokoa.Request, limit int) (interface{}, error) {
+					return request.ReadForm(limit)
+				},
+			})
+		case "text":
+			parsers = append(parsers, parser{
+				types: []string{"text/plain"},
+				limit: textLimit,
+				parse: func(request *gokoa.Request, limit int) (interface{}, error) {
+					return request.ReadText(limit)
+				},
+			})
+		default:
+			panic("bodyparser: unknown type " + typ)
+		}
+	}
+
+	return func(ctx *gokoa.Context, next func() error) error {
+		if ctx.Request.GetBody() != nil {
+			return next()
+		}
+
+		for _, p := range parsers {
+			if _, ok := ctx.Request.Is(p.types...); !ok {
+				continue
+			}
+
+			body, err := p.parse(ctx.Request, p.limit)
+			if err != nil {
+				statusCode := http.StatusBadRequest
+				switch err {
+				case gokoa.ErrBodyTooLarge:
+					statusCode = http.StatusRequestEntityTooLarge
+				case gokoa.ErrUnsupportedCharset:
+					statusCode = http.StatusUnsupportedMediaType
+				}
+				ctx.SetBody(err.Error())
+				ctx.SetStatus(statusCode)
+				return nil
+			}
+
+			ctx.Request.SetBody(body)
+			break
+		}
+
+		return next()
+	}
+}
+
+// parseJSON parses the request body as JSON.
+//
+// In the strict mode, only JSON objects and arrays are accepted.
+func parseJSON(request *gokoa.Request, limit int, strict bool) (interface{}, error) {
+	text, err := request.ReadText(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace([]byte(text))
+	if len(trimmed) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	if strict && trimmed[0] != '{' && trimmed[0] != '[' {
+		return nil, errNotStrict
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(trimmed, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,141 @@
+package bodyparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// serve posts the given body of the given type to an Application
+// using the given body parser, and returns the response, the parsed
+// body and the raw body.
+func serve(t *testing.T, config Config, contentType string, body string) (*http.Response, interface{}, []byte) {
+	var parsed interface{}
+	var raw []byte
+
+	app := gokoa.NewApplication(nil)
+	app.Use(New(config)).Use(func(ctx *gokoa.Context, next func() error) error {
+		parsed = ctx.Request.GetBody()
+		raw = ctx.Request.GetRawBody()
+		ctx.SetStatus(http.StatusOK)
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	app.Callback()(rec, req)
+
+	res := rec.Result()
+	_, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	return res, parsed, raw
+}
+
+func TestNew_JSON(t *testing.T) {
+	var res *http.Response
+	var body interface{}
+	var raw []byte
+
+	// act
+	res, body, raw = serve(t, nil, "application/json", `{"name":"gokoa","tags":["koa"]}`)
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, map[string]interface{}{"name": "gokoa", "tags": []interface{}{"koa"}}, body)
+	assert.Equal(t, []byte(`{"name":"gokoa","tags":["koa"]}`), raw)
+
+	// act
+	res, body, _ = serve(t, nil, "application/vnd.api+json", `[1, 2]`)
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, body)
+
+	// act
+	res, _, _ = serve(t, nil, "application/json", `"gokoa"`)
+
+	// assert
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// act
+	res, body, _ = serve(t, Config{"strict": false}, "application/json", `"gokoa"`)
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "gokoa", body)
+
+	// act
+	res, _, _ = serve(t, nil, "application/json", `{"name":`)
+
+	// assert
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestNew_Form(t *testing.T) {
+	var res *http.Response
+	var body interface{}
+
+	// act
+	res, body, _ = serve(t, nil, "application/x-www-form-urlencoded", "name=gokoa&tags=koa&tags=go")
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, url.Values{"name": {"gokoa"}, "tags": {"koa", "go"}}, body)
+}
+
+func TestNew_Text(t *testing.T) {
+	var body interface{}
+
+	// act
+	_, body, _ = serve(t, nil, "text/plain", "hello gokoa")
+
+	// assert
+	assert.Equal(t, nil, body)
+
+	// act
+	_, body, _ = serve(t, Config{"enableTypes": []string{"text"}}, "text/plain; charset=iso-8859-1", "caf\xE9")
+
+	// assert
+	assert.Equal(t, "café", body)
+}
+
+func TestNew_Limit(t *testing.T) {
+	var res *http.Response
+
+	// act
+	res, _, _ = serve(t, Config{"jsonLimit": 8}, "application/json", `{"name":"gokoa"}`)
+
+	// assert
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+
+	// act
+	res, _, _ = serve(t, Config{"formLimit": 8}, "application/x-www-form-urlencoded", "name=gokoa")
+
+	// assert
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+}
+
+func TestNew_UnsupportedCharset(t *testing.T) {
+	var res *http.Response
+
+	// act
+	res, _, _ = serve(t, nil, "application/json; charset=gbk", `{}`)
+
+	// assert
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
+
+func TestNew_UnknownType(t *testing.T) {
+	// assert
+	assert.Panics(t, func() {
+		New(Config{"enableTypes": []string{"xml"}})
+	})
+}
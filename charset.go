@@ -0,0 +1,62 @@
+package gokoa
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedCharset is returned when a charset can NOT be decoded.
+var ErrUnsupportedCharset = errors.New("unsupported charset")
+
+// decodeCharset decodes the given bytes encoded in the given charset
+// into a UTF-8 string.
+//
+// The supported charsets are UTF-8, US-ASCII, ISO-8859-1, UTF-16,
+// UTF-16BE and UTF-16LE, and an empty charset means UTF-8.
+//
+// decodeCharset returns ErrUnsupportedCharset when the charset is NOT
+// supported.
+func decodeCharset(raw []byte, charset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		if len(raw) >= 3 && raw[0] == 0xEF && raw[1] == 0xBB && raw[2] == 0xBF {
+			raw = raw[3:]
+		}
+		if !utf8.Valid(raw) {
+			return strings.ToValidUTF8(string(raw), string(utf8.RuneError)), nil
+		}
+		return string(raw), nil
+	case "iso-8859-1", "latin1", "l1":
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+	case "utf-16", "utf16":
+		if len(raw) >= 2 && raw[0] == 0xFF && raw[1] == 0xFE {
+			return decodeUTF16(raw[2:], binary.LittleEndian), nil
+		}
+		if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+			raw = raw[2:]
+		}
+		return decodeUTF16(raw, binary.BigEndian), nil
+	case "utf-16be":
+		return decodeUTF16(raw, binary.BigEndian), nil
+	case "utf-16le":
+		return decodeUTF16(raw, binary.LittleEndian), nil
+	}
+	return "", ErrUnsupportedCharset
+}
+
+// decodeUTF16 decodes the given bytes encoded in UTF-16 with the given
+// byte order into a UTF-8 string, ignoring the trailing odd byte.
+func decodeUTF16(raw []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = order.Uint16(raw[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,45 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecodeCharset(t *testing.T) {
+	var text string
+	var err error
+
+	// act
+	text, err = decodeCharset([]byte("\xEF\xBB\xBFhello 世界"), "UTF-8")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "hello 世界", text)
+
+	// act
+	text, err = decodeCharset([]byte("caf\xE9"), "ISO-8859-1")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "café", text)
+
+	// act
+	text, err = decodeCharset([]byte("\xFF\xFEh\x00i\x00"), "utf-16")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", text)
+
+	// act
+	text, err = decodeCharset([]byte("\x00h\x00i"), "utf-16be")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", text)
+
+	// act
+	_, err = decodeCharset([]byte("hi"), "gbk")
+
+	// assert
+	assert.Equal(t, ErrUnsupportedCharset, err)
+}
@@ -0,0 +1,33 @@
+// Package conditional provides a middleware for gokoa, which responds
+// to conditional GET requests with 304 Not Modified when the response
+// is still fresh in the cache of the client.
+//
+// The middleware relies on the ETag or the Last-Modified header of the
+// response, therefore it should be used before middlewares setting
+// them, e.g. the etag middleware:
+//
+//	app.Use(conditional.New())
+//	app.Use(etag.New())
+package conditional
+
+import (
+	"net/http"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// New returns a Middleware, which replaces the response with an empty
+// 304 Not Modified one after next returns, when the request is fresh.
+func New() gokoa.Middleware {
+	return func(ctx *gokoa.Context, next func() error) error {
+		if err := next(); err != nil {
+			return err
+		}
+
+		if ctx.Request.IsFresh() {
+			ctx.SetStatus(http.StatusNotModified)
+			ctx.SetBody(nil)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package conditional
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var app *gokoa.Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var res *http.Response
+	var body []byte
+	var err error
+
+	// arrange
+	app = gokoa.NewApplication(nil)
+	app.Use(New()).Use(func(ctx *gokoa.Context, next func() error) error {
+		ctx.Response.SetEtag("abc")
+		ctx.SetBody("hello gokoa")
+		return nil
+	})
+
+	for _, etag := range []string{`"abc"`, `"xyz"`} {
+		// arrange
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", etag)
+
+		// act
+		app.Callback()(rec, req)
+		res = rec.Result()
+		body, err = ioutil.ReadAll(res.Body)
+		assert.Nil(t, err)
+		defer res.Body.Close()
+
+		// assert
+		if etag == `"abc"` {
+			assert.Equal(t, http.StatusNotModified, res.StatusCode)
+			assert.Empty(t, body)
+			assert.Equal(t, "", res.Header.Get("Content-Length"))
+		} else {
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, []byte("hello gokoa"), body)
+		}
+	}
+}
@@ -1,5 +1,23 @@
 package gokoa
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrRouteNotFound is returned when no route is named as the given
+// name.
+var ErrRouteNotFound = errors.New("route not found")
+
+// A URLGenerator generates URLs of named routes, e.g. a *router.Router.
+//
+// URL returns an error wrapping ErrRouteNotFound when no route is
+// named as the given name.
+type URLGenerator interface {
+	URL(name string, params map[string]string, query url.Values) (string, error)
+}
+
 // A Context contains information related to a single HTTP request.
 type Context struct {
 	Request *Request
@@ -9,31 +27,127 @@ type Context struct {
 	// State is the recommended namespace for passing information
 	// through different middlewares.
 	State map[string]interface{}
+
+	// Params contains named parameters captured from the request path
+	// by a router, e.g. "id" for a route "/users/:id".
+	Params map[string]string
+
+	// Cookies gets and sets cookies, which are signed with keys of the
+	// Application by default.
+	Cookies *Cookies
+
+	// finishers are functions that will be executed after the HTTP
+	// request is handled.
+	finishers []func()
+
+	// urlGenerators generate URLs of named routes, e.g. routers which
+	// the request passes through.
+	urlGenerators []URLGenerator
 }
 
 // NewContext returns a new empty Context.
 //
 // NewContext allocates memory for State, which means that a key-value
 // pair can be directly added to State, without calling make() by
-// yourself.
+// yourself. The same applies to Params.
 func NewContext() *Context {
-	return &Context{
+	ctx := &Context{
 		State: make(map[string]interface{}),
+		Params: make(map[string]string),
 	}
+	ctx.Cookies = &Cookies{ctx: ctx}
+	return ctx
 }
 
 func (ctx *Context) GetStatus() int {
 	return ctx.Response.GetStatus()
 }
 
-func (ctx *Context) SetStatus(statusCode int) {
-	ctx.Response.SetStatus(statusCode)
+func (ctx *Context) SetStatus(statusCode int) error {
+	return ctx.Response.SetStatus(statusCode)
 }
 
 func (ctx *Context) GetBody() []byte {
 	return ctx.Response.GetBody()
 }
 
-func (ctx *Context) SetBody(body interface{}) {
-	ctx.Response.SetBody(body)
+func (ctx *Context) SetBody(body interface{}) error {
+	return ctx.Response.SetBody(body)
+}
+
+// Redirect redirects the client to the given URL, see
+// Response.Redirect for details.
+func (ctx *Context) Redirect(url string, alt ...string) error {
+	return ctx.Response.Redirect(url, alt...)
+}
+
+// RedirectTo redirects the client to the URL of the route named as the
+// given name, with the given parameters and query string, e.g.
+//
+//	return ctx.RedirectTo("user.show", map[string]string{"id": "1"}, nil)
+//
+// The route is looked up by URL, see it for details.
+func (ctx *Context) RedirectTo(name string, params map[string]string, query url.Values) error {
+	location, err := ctx.URL(name, params, query)
+	if err != nil {
+		return err
+	}
+	return ctx.Redirect(location)
+}
+
+// UseURLGenerator registers the given URLGenerator, which is used by
+// URL and RedirectTo to look up named routes. A router registers
+// itself when a request passes through it.
+func (ctx *Context) UseURLGenerator(generator URLGenerator) {
+	ctx.urlGenerators = append(ctx.urlGenerators, generator)
+}
+
+// URL returns a URL generated from the route named as the given name,
+// with the given parameters and query string, which is looked up in
+// URLGenerators registered by UseURLGenerator, from the latest one.
+func (ctx *Context) URL(name string, params map[string]string, query url.Values) (string, error) {
+	for i := len(ctx.urlGenerators) - 1; i >= 0; i-- {
+		location, err := ctx.urlGenerators[i].URL(name, params, query)
+		if errors.Is(err, ErrRouteNotFound) {
+			continue
+		}
+		return location, err
+	}
+	return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+}
+
+// Attachment prompts the client to download the HTTP response body
+// as the given filename, see Response.Attachment for details.
+func (ctx *Context) Attachment(filename string, opts AttachmentOptions) error {
+	return ctx.Response.Attachment(filename, opts)
+}
+
+// setApp makes the Context, its Request and its Response belong to
+// the given Application.
+func (ctx *Context) setApp(app *Application) {
+	ctx.app = app
+	if ctx.Request != nil {
+		ctx.Request.app = app
+	}
+	if ctx.Response != nil {
+		ctx.Response.app = app
+	}
+}
+
+// OnFinished registers the given function, which will be executed
+// after the HTTP request is handled and the HTTP response is sent,
+// even if any middleware returns an error.
+//
+// Functions are executed in the reverse order of registration, which
+// is useful for releasing resources like temporary files.
+func (ctx *Context) OnFinished(fn func()) {
+	ctx.finishers = append(ctx.finishers, fn)
+}
+
+// finish executes functions registered by OnFinished.
+func (ctx *Context) finish() {
+	for i := len(ctx.finishers) - 1; i >= 0; i-- {
+		ctx.finishers[i]()
+	}
+	ctx.finishers = nil
 }
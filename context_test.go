@@ -1,7 +1,10 @@
 package gokoa
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -16,4 +19,90 @@ func TestNewContext(t *testing.T) {
 	assert.Equal(t, (*Response)(nil), ctx.Response)
 	assert.Equal(t, (*Application)(nil), ctx.app)
 	assert.Equal(t, make(map[string]interface{}), ctx.State)
+	assert.Equal(t, make(map[string]string), ctx.Params)
+}
+
+func TestContext_OnFinished(t *testing.T) {
+	var ctx *Context
+	var calls []int
+
+	// arrange
+	ctx = NewContext()
+	ctx.OnFinished(func() {
+		calls = append(calls, 1)
+	})
+	ctx.OnFinished(func() {
+		calls = append(calls, 2)
+	})
+
+	// act
+	ctx.finish()
+	ctx.finish()
+
+	// assert
+	assert.Equal(t, []int{ 2, 1 }, calls)
+}
+
+func TestContext_SetBody(t *testing.T) {
+	var ctx *Context
+	var err error
+
+	// arrange
+	ctx = NewContext()
+	ctx.Response = NewResponse()
+	ctx.Response.Res = httptest.NewRecorder()
+
+	// act
+	err = ctx.SetBody(func() {})
+
+	// assert
+	assert.NotNil(t, err)
+
+	// act
+	err = ctx.SetBody(map[string]string{ "hello": "gokoa" })
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"hello":"gokoa"}`), ctx.GetBody())
+}
+
+// staticURLGenerator generates URLs from a map of route names to URLs.
+type staticURLGenerator map[string]string
+
+func (generator staticURLGenerator) URL(name string, params map[string]string, query url.Values) (string, error) {
+	if location, ok := generator[name]; ok {
+		return location, nil
+	}
+	return "", ErrRouteNotFound
+}
+
+func TestContext_URL(t *testing.T) {
+	var ctx *Context
+	var location string
+	var err error
+
+	// arrange
+	ctx = NewContext()
+	ctx.UseURLGenerator(staticURLGenerator{ "home": "/", "user.show": "/users/1" })
+	ctx.UseURLGenerator(staticURLGenerator{ "user.show": "/members/1" })
+
+	// act
+	location, err = ctx.URL("home", nil, nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/", location)
+
+	// act
+	location, err = ctx.URL("user.show", nil, nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/members/1", location)
+
+	// act
+	_, err = ctx.URL("unknown", nil, nil)
+
+	// assert
+	assert.True(t, errors.Is(err, ErrRouteNotFound))
 }
@@ -0,0 +1,276 @@
+package gokoa
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInsecureCookie is returned when setting a secure cookie over an
+// unencrypted connection.
+var ErrInsecureCookie = errors.New("gokoa: cannot send secure cookie over unencrypted connection")
+
+// ErrKeysRequired is returned when setting a signed cookie without
+// keys of the Application.
+var ErrKeysRequired = errors.New("gokoa: keys required for signed cookies")
+
+// ErrInvalidCookie is returned when setting a cookie whose name or
+// value contains characters NOT allowed in the Set-Cookie header.
+var ErrInvalidCookie = errors.New("gokoa: invalid cookie name or value")
+
+// A CookieOptions is a container which stores settings for getting and
+// setting a cookie.
+//
+// The CookieOptions is organized as key-value pairs, where the value
+// is typed according to the key:
+//
+//	"path"        string        default to "/"
+//	"domain"      string        default to no domain
+//	"expires"     time.Time     default to a session cookie
+//	"maxAge"      time.Duration default to a session cookie, also when non-positive
+//	"secure"      bool          default to whether the request is secure
+//	"httpOnly"    bool          default to true
+//	"sameSite"    string        "strict", "lax" or "none", or true for "strict"
+//	"partitioned" bool          default to false
+//	"signed"      bool          default to whether the Application has keys
+type CookieOptions map[string]interface{}
+
+// Cookies gets and sets cookies of a single HTTP request.
+type Cookies struct {
+	ctx *Context
+}
+
+// Get returns the value of the cookie with the given name sent by the
+// client, and true when it exists.
+//
+// When the cookie is signed, the signature in the companion cookie
+// named "<name>.sig" is verified against keys of the Application, and
+// the cookie is regarded as absent when the signature is invalid.
+// The signature is renewed with the first key when it is signed by an
+// older key.
+func (cookies *Cookies) Get(name string, opts CookieOptions) (string, bool) {
+	if opts == nil {
+		opts = make(CookieOptions)
+	}
+
+	cookie, err := cookies.ctx.Request.Req.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	if !cookies.signed(opts) {
+		return cookie.Value, true
+	}
+
+	signature, err := cookies.ctx.Request.Req.Cookie(name + ".sig")
+	if err != nil {
+		return "", false
+	}
+
+	keygrip := cookies.keygrip()
+	if keygrip == nil {
+		return "", false
+	}
+
+	sigOpts := CookieOptions{"signed": false}
+	for key, value := range opts {
+		if key != "signed" {
+			sigOpts[key] = value
+		}
+	}
+
+	data := name + "=" + cookie.Value
+
+	index := keygrip.Index(data, signature.Value)
+	if index == -1 {
+		// the signature is invalid, so that it is removed
+		cookies.Set(name+".sig", "", sigOpts)
+		return "", false
+	}
+	if index > 0 {
+		// the signature is signed by an old key, so that it is renewed
+		cookies.Set(name+".sig", keygrip.Sign(data), sigOpts)
+	}
+	return cookie.Value, true
+}
+
+// Set sends a cookie with the given name and value to the client,
+// which replaces any cookie with the same name set before. An empty
+// value deletes the cookie.
+//
+// When the cookie is signed, a companion cookie named "<name>.sig" is
+// sent with the signature of the cookie, signed by the first key of
+// the Application.
+//
+// Set returns ErrInsecureCookie when setting a secure cookie over an
+// unencrypted connection, where the protocol is determined with proxy
+// headers when the proxy is trusted, and ErrInvalidCookie when the name
+// is NOT a token or the value contains control characters, '"', ';'
+// or '\\', which should be encoded first, e.g. by base64.
+func (cookies *Cookies) Set(name string, value string, opts CookieOptions) error {
+	if opts == nil {
+		opts = make(CookieOptions)
+	}
+
+	response := cookies.ctx.Response
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
+	if !validCookieName(name) || !validCookieValue(value) {
+		return ErrInvalidCookie
+	}
+
+	secure := cookies.ctx.Request.IsSecure()
+	if value, ok := opts["secure"]; ok {
+		if value.(bool) && !secure {
+			return ErrInsecureCookie
+		}
+		secure = value.(bool)
+	}
+
+	signed := cookies.signed(opts)
+	if signed && cookies.keygrip() == nil {
+		return ErrKeysRequired
+	}
+
+	cookie := &http.Cookie{
+		Name: name,
+		Value: value,
+		Path: "/",
+		Secure: secure,
+		HttpOnly: true,
+	}
+	if path, ok := opts["path"]; ok {
+		cookie.Path = path.(string)
+	}
+	if domain, ok := opts["domain"]; ok {
+		cookie.Domain = domain.(string)
+	}
+	if expires, ok := opts["expires"]; ok {
+		cookie.Expires = expires.(time.Time)
+	}
+	if maxAge, ok := opts["maxAge"]; ok && maxAge.(time.Duration) > 0 {
+		// rounded up to seconds, so that a positive maxAge never means
+		// an expired cookie
+		seconds := (maxAge.(time.Duration) + time.Second - 1) / time.Second
+		cookie.MaxAge = int(seconds)
+		cookie.Expires = time.Now().Add(seconds * time.Second)
+	}
+	if httpOnly, ok := opts["httpOnly"]; ok {
+		cookie.HttpOnly = httpOnly.(bool)
+	}
+	if sameSite, ok := opts["sameSite"]; ok {
+		cookie.SameSite = parseSameSite(sameSite)
+	}
+	if value == "" {
+		cookie.Expires = time.Unix(0, 0)
+		cookie.MaxAge = -1
+	}
+
+	partitioned := false
+	if value, ok := opts["partitioned"]; ok {
+		partitioned = value.(bool)
+	}
+
+	cookies.push(cookie, partitioned)
+
+	if signed {
+		signature := *cookie
+		signature.Name = name + ".sig"
+		if value != "" {
+			signature.Value = cookies.keygrip().Sign(name + "=" + value)
+		}
+		cookies.push(&signature, partitioned)
+	}
+
+	return nil
+}
+
+// signed returns whether the cookie is signed according to the given
+// options, default to whether the Application has keys.
+func (cookies *Cookies) signed(opts CookieOptions) bool {
+	if signed, ok := opts["signed"]; ok {
+		return signed.(bool)
+	}
+	return cookies.keygrip() != nil
+}
+
+// keygrip returns the Keygrip of the Application, or nil when there is
+// no key.
+func (cookies *Cookies) keygrip() *Keygrip {
+	if cookies.ctx.app == nil {
+		return nil
+	}
+	return cookies.ctx.app.keygrip()
+}
+
+// push appends the given cookie to the Set-Cookie header, replacing
+// any cookie with the same name.
+func (cookies *Cookies) push(cookie *http.Cookie, partitioned bool) {
+	header := cookies.ctx.Response.Res.Header()
+
+	setCookies := []string{}
+	for _, setCookie := range header["Set-Cookie"] {
+		if !strings.HasPrefix(setCookie, cookie.Name+"=") {
+			setCookies = append(setCookies, setCookie)
+		}
+	}
+
+	setCookie := cookie.String()
+	if partitioned {
+		setCookie += "; Partitioned"
+	}
+	header["Set-Cookie"] = append(setCookies, setCookie)
+}
+
+// validCookieName returns true when the given name is a non-empty
+// token, see RFC 7230 section 3.2.6.
+func validCookieName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// validCookieValue returns true when the given value can be sent in the
+// Set-Cookie header as it is, where spaces and commas are allowed since
+// the value is quoted then.
+func validCookieValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < ' ' || c >= 0x7f || c == '"' || c == ';' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSameSite returns the http.SameSite corresponding to the given
+// value, which is either a string or a bool, or 0 when the SameSite
+// attribute should be omitted.
+func parseSameSite(value interface{}) http.SameSite {
+	if value, ok := value.(bool); ok {
+		if value {
+			return http.SameSiteStrictMode
+		}
+		return 0
+	}
+
+	switch strings.ToLower(value.(string)) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return 0
+	}
+}
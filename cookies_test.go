@@ -0,0 +1,228 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCookieContext(app *Application, req *http.Request) *Context {
+	return app.createContext(httptest.NewRecorder(), req)
+}
+
+func TestCookies_Set(t *testing.T) {
+	var ctx *Context
+	var err error
+
+	// arrange
+	ctx = newCookieContext(NewApplication(nil), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// act
+	err = ctx.Cookies.Set("name", "gokoa", CookieOptions{
+		"domain": "example.com",
+		"maxAge": time.Hour,
+		"sameSite": "lax",
+		"partitioned": true,
+	})
+
+	// assert
+	assert.Nil(t, err)
+	assert.Len(t, ctx.Response.Res.Header()["Set-Cookie"], 1)
+	setCookie := ctx.Response.Get("Set-Cookie")
+	assert.True(t, strings.HasPrefix(setCookie, "name=gokoa; Path=/; Domain=example.com; Expires="))
+	assert.Contains(t, setCookie, "; Max-Age=3600; HttpOnly; SameSite=Lax; Partitioned")
+	assert.NotContains(t, setCookie, "Secure")
+
+	// act
+	err = ctx.Cookies.Set("name", "", CookieOptions{ "httpOnly": false })
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ "name=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0" }, ctx.Response.Res.Header()["Set-Cookie"])
+}
+
+func TestCookies_Set_Secure(t *testing.T) {
+	var app *Application
+	var req *http.Request
+	var ctx *Context
+	var err error
+
+	// arrange
+	app = NewApplication(ApplicationConfig{ "proxy": true })
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx = newCookieContext(app, req)
+
+	// act
+	err = ctx.Cookies.Set("name", "gokoa", CookieOptions{ "secure": true })
+
+	// assert
+	assert.Equal(t, ErrInsecureCookie, err)
+	assert.False(t, ctx.Response.Has("Set-Cookie"))
+
+	// arrange
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	// act
+	err = ctx.Cookies.Set("name", "gokoa", nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Contains(t, ctx.Response.Get("Set-Cookie"), "; Secure")
+}
+
+func TestCookies_Signed(t *testing.T) {
+	var app *Application
+	var ctx *Context
+	var req *http.Request
+	var value string
+	var ok bool
+	var err error
+
+	// arrange
+	app = NewApplication(ApplicationConfig{ "keys": []string{ "new key", "old key" } })
+	ctx = newCookieContext(app, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// act
+	err = ctx.Cookies.Set("name", "gokoa", nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"name=gokoa; Path=/; HttpOnly",
+		"name.sig=" + NewKeygrip([]string{ "new key" }, "").Sign("name=gokoa") + "; Path=/; HttpOnly",
+	}, ctx.Response.Res.Header()["Set-Cookie"])
+
+	// arrange
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", "name=gokoa; name.sig=" + NewKeygrip([]string{ "old key" }, "").Sign("name=gokoa"))
+	ctx = newCookieContext(app, req)
+
+	// act
+	value, ok = ctx.Cookies.Get("name", nil)
+
+	// assert
+	assert.True(t, ok)
+	assert.Equal(t, "gokoa", value)
+	assert.Equal(t, "name.sig=" + NewKeygrip([]string{ "new key" }, "").Sign("name=gokoa") + "; Path=/; HttpOnly", ctx.Response.Get("Set-Cookie"))
+
+	// arrange
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", "name=gokoa; name.sig=invalid")
+	ctx = newCookieContext(app, req)
+
+	// act
+	value, ok = ctx.Cookies.Get("name", nil)
+
+	// assert
+	assert.False(t, ok)
+	assert.Equal(t, "", value)
+	assert.True(t, strings.HasPrefix(ctx.Response.Get("Set-Cookie"), "name.sig=; Path=/; Expires=Thu, 01 Jan 1970"))
+
+	// act
+	value, ok = ctx.Cookies.Get("name", CookieOptions{ "signed": false })
+
+	// assert
+	assert.True(t, ok)
+	assert.Equal(t, "gokoa", value)
+}
+
+func TestCookies_Set_Invalid(t *testing.T) {
+	var ctx *Context
+	var err error
+
+	// arrange
+	ctx = newCookieContext(NewApplication(ApplicationConfig{ "keys": []string{ "key" } }), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for _, cookie := range [][2]string{
+		{ "pref", `{"a":"b;c"}` },
+		{ "pref", `a\b` },
+		{ "pref", "a\nb" },
+		{ "", "gokoa" },
+		{ "my pref", "gokoa" },
+		{ "pref;", "gokoa" },
+	} {
+		// act
+		err = ctx.Cookies.Set(cookie[0], cookie[1], nil)
+
+		// assert
+		assert.Equal(t, ErrInvalidCookie, err)
+		assert.False(t, ctx.Response.Has("Set-Cookie"))
+	}
+
+	// act
+	err = ctx.Cookies.Set("pref", "a b,c", nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Len(t, ctx.Response.Res.Header()["Set-Cookie"], 2)
+
+	// arrange
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range (&http.Response{ Header: ctx.Response.GetHeaders() }).Cookies() {
+		req.AddCookie(cookie)
+	}
+	ctx = newCookieContext(ctx.app, req)
+
+	// act
+	value, ok := ctx.Cookies.Get("pref", nil)
+
+	// assert
+	assert.True(t, ok)
+	assert.Equal(t, "a b,c", value)
+}
+
+func TestCookies_Set_KeysRequired(t *testing.T) {
+	var ctx *Context
+	var err error
+
+	// arrange
+	ctx = newCookieContext(NewApplication(nil), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// act
+	err = ctx.Cookies.Set("name", "gokoa", CookieOptions{ "signed": true })
+
+	// assert
+	assert.Equal(t, ErrKeysRequired, err)
+}
+
+func TestCookies_Keygrip(t *testing.T) {
+	var app *Application
+	var keygrip *Keygrip
+	var ctx *Context
+
+	// arrange
+	keygrip = NewKeygrip([]string{ "key" }, "sha256")
+	app = NewApplication(ApplicationConfig{ "keygrip": keygrip })
+	ctx = newCookieContext(app, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// act
+	ctx.Cookies.Set("name", "gokoa", nil)
+
+	// assert
+	assert.Equal(t, "name.sig=" + keygrip.Sign("name=gokoa") + "; Path=/; HttpOnly", ctx.Response.Res.Header()["Set-Cookie"][1])
+}
+
+func TestCookies_Set_MaxAge(t *testing.T) {
+	var ctx *Context
+	var cookie *http.Cookie
+
+	// arrange
+	ctx = newCookieContext(NewApplication(nil), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// act
+	ctx.Cookies.Set("name", "gokoa", CookieOptions{ "maxAge": time.Duration(0) })
+
+	// assert
+	assert.Equal(t, "name=gokoa; Path=/; HttpOnly", ctx.Response.Get("Set-Cookie"))
+
+	// act
+	ctx.Cookies.Set("name", "gokoa", CookieOptions{ "maxAge": 500 * time.Millisecond })
+	cookie = (&http.Response{ Header: ctx.Response.GetHeaders() }).Cookies()[0]
+
+	// assert
+	assert.Equal(t, 1, cookie.MaxAge)
+	assert.True(t, cookie.Expires.After(time.Now()))
+}
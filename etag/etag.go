@@ -0,0 +1,67 @@
+// Package etag provides a middleware for gokoa, which generates the
+// ETag header for HTTP responses automatically.
+//
+// Together with the conditional middleware, unchanged responses are
+// answered with 304 Not Modified:
+//
+//	app.Use(conditional.New())
+//	app.Use(etag.New())
+package etag
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// New returns a Middleware, which sets the ETag header of the response
+// after next returns, unless it is already set or the status code is
+// NOT 2xx.
+//
+// A weak ETag is generated from the size and the modification time
+// for a response body read from a file, and a strong ETag is
+// generated from the hash of the content for other response bodies.
+// No ETag is generated for an empty response body, or a stream NOT
+// read from a file.
+func New() gokoa.Middleware {
+	return func(ctx *gokoa.Context, next func() error) error {
+		if err := next(); err != nil {
+			return err
+		}
+
+		if ctx.Response.Has("ETag") {
+			return nil
+		}
+
+		if statusCode := ctx.GetStatus(); statusCode < 200 || statusCode >= 300 {
+			return nil
+		}
+
+		if info := ctx.Response.GetFileInfo(); info != nil {
+			ctx.Response.SetEtag(Stat(info))
+			return nil
+		}
+
+		if body := ctx.GetBody(); body != nil {
+			ctx.Response.SetEtag(Calculate(body))
+		}
+		return nil
+	}
+}
+
+// Calculate returns a strong ETag for the given content, which
+// consists of the length and the SHA-1 hash of the content, e.g.
+// "0-2jmj7l5rSw0yVb/vlWAYkK/YBwk" for an empty content.
+func Calculate(content []byte) string {
+	hash := sha1.Sum(content)
+	return fmt.Sprintf(`"%x-%s"`, len(content), base64.StdEncoding.EncodeToString(hash[:])[:27])
+}
+
+// Stat returns a weak ETag for the given file, which consists of the
+// size and the modification time in milliseconds of the file.
+func Stat(info os.FileInfo) string {
+	return fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()/int64(1e6))
+}
@@ -0,0 +1,110 @@
+package etag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"github.com/xiaojianzhong/gokoa/conditional"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// fileInfo is a fake os.FileInfo with fixed size and modification
+// time.
+type fileInfo struct {
+	os.FileInfo
+}
+
+func (fileInfo) Size() int64 {
+	return 1024
+}
+
+func (fileInfo) ModTime() time.Time {
+	return time.Unix(1, 0)
+}
+
+func TestCalculate(t *testing.T) {
+	// assert
+	assert.Equal(t, `"0-2jmj7l5rSw0yVb/vlWAYkK/YBwk"`, Calculate([]byte{}))
+	assert.Equal(t, `"b-glX02zxRbFNhKsdqzeuDNucwsu8"`, Calculate([]byte("hello gokoa")))
+}
+
+func TestStat(t *testing.T) {
+	// assert
+	assert.Equal(t, `W/"400-3e8"`, Stat(fileInfo{}))
+}
+
+func TestNew(t *testing.T) {
+	var app *gokoa.Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var res *http.Response
+	var body []byte
+	var err error
+
+	// arrange
+	app = gokoa.NewApplication(nil)
+	app.Use(conditional.New()).Use(New()).Use(func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody(map[string]interface{}{"hello": "gokoa"})
+		return nil
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+	res = rec.Result()
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, Calculate([]byte(`{"hello":"gokoa"}`)), res.Header.Get("ETag"))
+
+	// arrange
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", res.Header.Get("ETag"))
+
+	// act
+	app.Callback()(rec, req)
+	res = rec.Result()
+	body, err = ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	// assert
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	assert.Empty(t, body)
+}
+
+func TestNew_Skipped(t *testing.T) {
+	var app *gokoa.Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var res *http.Response
+
+	// arrange
+	app = gokoa.NewApplication(nil)
+	app.Use(New()).Use(func(ctx *gokoa.Context, next func() error) error {
+		if ctx.Request.GetPath() == "/etag" {
+			ctx.Response.SetEtag("custom")
+			ctx.SetBody("hello gokoa")
+		}
+		return nil
+	})
+
+	for path, etag := range map[string]string{"/etag": `"custom"`, "/missing": ""} {
+		// arrange
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, path, nil)
+
+		// act
+		app.Callback()(rec, req)
+		res = rec.Result()
+
+		// assert
+		assert.Equal(t, etag, res.Header.Get("ETag"))
+	}
+}
@@ -0,0 +1,198 @@
+package gokoa
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// An HttpError is an error carrying the HTTP status code, which will be
+// sent back to the client when it is returned by a middleware.
+type HttpError struct {
+	// Status is the HTTP status code, e.g. 404.
+	Status int
+
+	// Message is the message of the HttpError, default to the status
+	// text, e.g. "Not Found".
+	Message string
+
+	// Expose is true when Message can be sent back to the client,
+	// default to true for 4xx status codes, and false for 5xx ones, in
+	// which case only the status text is sent.
+	Expose bool
+
+	// Headers are HTTP response headers sent along with the HttpError,
+	// e.g. "Retry-After" for 503 Service Unavailable.
+	Headers map[string]string
+
+	// Props are extra properties of the HttpError, e.g. an error code
+	// of the business.
+	Props map[string]interface{}
+
+	// Err is the underlying error, or nil when there is none.
+	Err error
+}
+
+// NewHttpError returns a new HttpError with the given status code,
+// message and properties.
+//
+// The message can be empty, which means the status text. The props
+// can be nil, and the following keys are treated specially, while the
+// others are stored in Props:
+//
+//	expose   bool                            overrides Expose
+//	headers  map[string]string or http.Header  stored in Headers
+//	err      error                           stored in Err, ignored when nil
+//
+// A value of an unexpected type for these keys is stored in Props as
+// well. Multiple values of a header in an http.Header are joined with
+// commas.
+//
+// A status code which is NOT an error, i.e. less than 400, is replaced
+// with 500.
+func NewHttpError(status int, message string, props map[string]interface{}) *HttpError {
+	if status < 400 || status > 599 {
+		status = http.StatusInternalServerError
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	httpErr := &HttpError{
+		Status: status,
+		Message: message,
+		Expose: status < 500,
+		Props: make(map[string]interface{}),
+	}
+
+	for key, value := range props {
+		switch key {
+		case "expose":
+			if expose, ok := value.(bool); ok {
+				httpErr.Expose = expose
+				continue
+			}
+		case "headers":
+			switch headers := value.(type) {
+			case map[string]string:
+				httpErr.Headers = headers
+				continue
+			case http.Header:
+				httpErr.Headers = make(map[string]string, len(headers))
+				for field, values := range headers {
+					httpErr.Headers[field] = strings.Join(values, ", ")
+				}
+				continue
+			}
+		case "err":
+			if value == nil {
+				continue
+			}
+			if err, ok := value.(error); ok {
+				httpErr.Err = err
+				continue
+			}
+		}
+		httpErr.Props[key] = value
+	}
+
+	return httpErr
+}
+
+// Error returns the message of the HttpError.
+func (err *HttpError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("%s: %v", err.Message, err.Err)
+	}
+	return err.Message
+}
+
+// Unwrap returns the underlying error.
+func (err *HttpError) Unwrap() error {
+	return err.Err
+}
+
+// Throw returns an HttpError with the given status code, message and
+// properties, see NewHttpError for details, e.g.
+//
+//	return ctx.Throw(http.StatusNotFound, "user not found", nil)
+//
+// The HttpError is sent back to the client, when it is returned by the
+// middleware.
+func (ctx *Context) Throw(status int, message string, props map[string]interface{}) error {
+	return NewHttpError(status, message, props)
+}
+
+// Assert returns an HttpError with the given status code and message
+// when the given condition is false, or nil otherwise, e.g.
+//
+//	if err := ctx.Assert(user != nil, http.StatusUnauthorized, "please login"); err != nil {
+//		return err
+//	}
+func (ctx *Context) Assert(condition bool, status int, message string) error {
+	if condition {
+		return nil
+	}
+	return ctx.Throw(status, message, nil)
+}
+
+// toHttpError returns the HttpError corresponding to the given error,
+// which is 500 Internal Server Error with the message NOT exposed when
+// the error is NOT an HttpError nor a binding error.
+func toHttpError(err error) *HttpError {
+	var httpErr *HttpError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	if httpErr, ok := bindingHttpError(err); ok {
+		return httpErr
+	}
+	return NewHttpError(http.StatusInternalServerError, "", map[string]interface{}{
+		"err": err,
+	})
+}
+
+// RenderError is the default ErrorRenderer of the Application, which
+// renders the given HttpError as JSON, HTML or plain text according to
+// the Accept header, with JSON preferred.
+//
+// The message is rendered only when the HttpError is exposed,
+// otherwise the status text is rendered, e.g. "Internal Server Error".
+// Props of an exposed HttpError are rendered as well in JSON, e.g.
+//
+//	{"message":"validation failed","errors":[...]}
+func RenderError(ctx *Context, err *HttpError) {
+	message := http.StatusText(err.Status)
+	if err.Expose {
+		message = err.Message
+	}
+
+	accepted, _ := ctx.Request.Accepts("json", "html", "text")
+	switch accepted {
+	case "json":
+		body := map[string]interface{}{}
+		if err.Expose {
+			for key, value := range err.Props {
+				body[key] = value
+			}
+		}
+		body["message"] = message
+		if ctx.SetBody(body) != nil {
+			ctx.SetBody(map[string]interface{}{"message": message})
+		}
+	case "html":
+		title := html.EscapeString(fmt.Sprintf("%d %s", err.Status, http.StatusText(err.Status)))
+		ctx.Response.SetType("html")
+		ctx.SetBody(fmt.Sprintf(
+			"<!DOCTYPE html>\n<html>\n<head><title>%s</title></head>\n<body>\n<h1>%s</h1>\n<p>%s</p>\n</body>\n</html>\n",
+			title, title, html.EscapeString(message),
+		))
+	default:
+		ctx.Response.SetType("text")
+		ctx.SetBody(message)
+	}
+
+	ctx.SetStatus(err.Status)
+}
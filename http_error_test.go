@@ -0,0 +1,270 @@
+package gokoa
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHttpError(t *testing.T) {
+	var httpErr *HttpError
+	var cause error
+
+	// act
+	httpErr = NewHttpError(http.StatusNotFound, "", nil)
+
+	// assert
+	assert.Equal(t, http.StatusNotFound, httpErr.Status)
+	assert.Equal(t, "Not Found", httpErr.Message)
+	assert.True(t, httpErr.Expose)
+	assert.Equal(t, "Not Found", httpErr.Error())
+
+	// arrange
+	cause = errors.New("connection refused")
+
+	// act
+	httpErr = NewHttpError(http.StatusServiceUnavailable, "database unavailable", map[string]interface{}{
+		"headers": map[string]string{ "Retry-After": "60" },
+		"err": cause,
+		"code": "E_DB",
+	})
+
+	// assert
+	assert.False(t, httpErr.Expose)
+	assert.Equal(t, map[string]string{ "Retry-After": "60" }, httpErr.Headers)
+	assert.Equal(t, map[string]interface{}{ "code": "E_DB" }, httpErr.Props)
+	assert.Equal(t, "database unavailable: connection refused", httpErr.Error())
+	assert.True(t, errors.Is(httpErr, cause))
+
+	// act
+	httpErr = NewHttpError(http.StatusOK, "oops", map[string]interface{}{ "expose": true })
+
+	// assert
+	assert.Equal(t, http.StatusInternalServerError, httpErr.Status)
+	assert.True(t, httpErr.Expose)
+}
+
+func TestNewHttpError_Props(t *testing.T) {
+	var httpErr *HttpError
+
+	// act
+	httpErr = NewHttpError(http.StatusTooManyRequests, "", map[string]interface{}{
+		"headers": http.Header{ "Retry-After": { "60" }, "Vary": { "Origin", "Accept" } },
+		"err": nil,
+	})
+
+	// assert
+	assert.Equal(t, map[string]string{ "Retry-After": "60", "Vary": "Origin, Accept" }, httpErr.Headers)
+	assert.Nil(t, httpErr.Err)
+	assert.Equal(t, map[string]interface{}{}, httpErr.Props)
+
+	// act
+	httpErr = NewHttpError(http.StatusBadRequest, "", map[string]interface{}{
+		"headers": "Retry-After: 60",
+		"expose": "no",
+		"err": "oops",
+	})
+
+	// assert
+	assert.Nil(t, httpErr.Headers)
+	assert.True(t, httpErr.Expose)
+	assert.Nil(t, httpErr.Err)
+	assert.Equal(t, map[string]interface{}{ "headers": "Retry-After: 60", "expose": "no", "err": "oops" }, httpErr.Props)
+}
+
+func TestContext_Throw(t *testing.T) {
+	var ctx *Context
+	var err error
+	var httpErr *HttpError
+
+	// arrange
+	ctx = NewContext()
+
+	// act
+	err = ctx.Throw(http.StatusForbidden, "access denied", nil)
+
+	// assert
+	assert.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusForbidden, httpErr.Status)
+	assert.Equal(t, "access denied", httpErr.Message)
+
+	// act
+	err = ctx.Assert(true, http.StatusUnauthorized, "please login")
+
+	// assert
+	assert.Nil(t, err)
+
+	// act
+	err = ctx.Assert(false, http.StatusUnauthorized, "please login")
+
+	// assert
+	assert.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusUnauthorized, httpErr.Status)
+}
+
+func TestApplication_Callback_HttpError(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var status int
+	var message string
+
+	// arrange
+	app = NewApplication(nil)
+	app.Use(func(ctx *Context, next func() error) error {
+		return ctx.Throw(status, message, map[string]interface{}{
+			"headers": map[string]string{ "X-Reason": "test" },
+		})
+	})
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/plain")
+
+	// arrange
+	rec = httptest.NewRecorder()
+	status, message = http.StatusNotFound, "<b>user</b> not found"
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "test", rec.Header().Get("X-Reason"))
+	assert.Equal(t, "<b>user</b> not found", rec.Body.String())
+
+	// arrange
+	rec = httptest.NewRecorder()
+	status, message = http.StatusInternalServerError, "secret details"
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "Internal Server Error", rec.Body.String())
+}
+
+func TestRenderError(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+
+	// arrange
+	app = NewApplication(nil)
+	app.OnError(func(error) {})
+	app.Use(func(ctx *Context, next func() error) error {
+		ctx.Response.Set("X-Before", "error")
+		return ctx.Throw(http.StatusConflict, "<conflict>", map[string]interface{}{ "code": "E_CONFLICT" })
+	})
+
+	// arrange
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "", rec.Header().Get("X-Before"))
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"message":"<conflict>","code":"E_CONFLICT"}`, rec.Body.String())
+
+	// arrange
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "<title>409 Conflict</title>")
+	assert.Contains(t, rec.Body.String(), "<p>&lt;conflict&gt;</p>")
+}
+
+func TestApplication_Callback_Error(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var rendered *HttpError
+
+	// arrange
+	app = NewApplication(ApplicationConfig{
+		"errorRenderer": ErrorRenderer(func(ctx *Context, err *HttpError) {
+			rendered = err
+			ctx.SetBody("custom")
+			ctx.SetStatus(err.Status)
+		}),
+	})
+	app.OnError(func(error) {})
+	app.Use(func(ctx *Context, next func() error) error {
+		return errors.New("database is down")
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "custom", rec.Body.String())
+	assert.False(t, rendered.Expose)
+	assert.EqualError(t, rendered.Err, "database is down")
+}
+
+func TestApplication_Callback_ErrorWithoutBody(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+
+	// arrange
+	app = NewApplication(ApplicationConfig{
+		"errorRenderer": ErrorRenderer(func(ctx *Context, err *HttpError) {}),
+	})
+	app.OnError(func(error) {})
+	app.Use(func(ctx *Context, next func() error) error {
+		ctx.SetStatus(http.StatusAccepted)
+		ctx.SetBody("internal secret token=abc")
+		return errors.New("database is down")
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret")
+}
+
+func TestApplication_Callback_ErrorAfterHeaderSent(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+
+	// arrange
+	app = NewApplication(nil)
+	app.OnError(func(error) {})
+	app.Use(func(ctx *Context, next func() error) error {
+		ctx.SetStatus(http.StatusOK)
+		ctx.Response.FlushHeaders()
+		ctx.Response.Res.Write([]byte("partial"))
+		return errors.New("stream broken")
+	})
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+}
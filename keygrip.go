@@ -0,0 +1,86 @@
+package gokoa
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+)
+
+// A Keygrip signs and verifies data with a list of rotating keys,
+// e.g. for cookies, URLs or tokens.
+//
+// Data is always signed with the first key, while it is verified
+// against all keys, so that a new key can be prepended to the list and
+// an old key can be removed from the list later, without invalidating
+// all signatures at once.
+type Keygrip struct {
+	keys []string
+	hash func() hash.Hash
+}
+
+// NewKeygrip returns a new Keygrip with the given keys, from the newest
+// to the oldest, and the given HMAC algorithm, which is either "sha1",
+// "sha256" or "sha512". An empty algorithm means "sha1".
+//
+// NewKeygrip panics when no key is given or the algorithm is NOT
+// supported.
+func NewKeygrip(keys []string, algorithm string) *Keygrip {
+	if len(keys) == 0 {
+		panic("gokoa: keys must be provided")
+	}
+
+	keygrip := &Keygrip{
+		keys: append([]string(nil), keys...),
+	}
+
+	switch algorithm {
+	case "", "sha1":
+		keygrip.hash = sha1.New
+	case "sha256":
+		keygrip.hash = sha256.New
+	case "sha512":
+		keygrip.hash = sha512.New
+	default:
+		panic(fmt.Sprintf("gokoa: unsupported algorithm %q", algorithm))
+	}
+
+	return keygrip
+}
+
+// Sign returns the signature of the given data signed by the first
+// key, encoded in URL-safe base64 without padding.
+func (keygrip *Keygrip) Sign(data string) string {
+	return keygrip.sign(data, keygrip.keys[0])
+}
+
+// Verify returns true when the given signature of the given data is
+// signed by any key.
+func (keygrip *Keygrip) Verify(data string, signature string) bool {
+	return keygrip.Index(data, signature) != -1
+}
+
+// Index returns the index of the key by which the given signature of
+// the given data is signed, or -1 when it is signed by none of them.
+//
+// A positive index means that the signature is signed by an old key,
+// which should be replaced by a new signature returned by Sign.
+func (keygrip *Keygrip) Index(data string, signature string) int {
+	for i, key := range keygrip.keys {
+		if hmac.Equal([]byte(signature), []byte(keygrip.sign(data, key))) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sign returns the signature of the given data signed by the given
+// key.
+func (keygrip *Keygrip) sign(data string, key string) string {
+	mac := hmac.New(keygrip.hash, []byte(key))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
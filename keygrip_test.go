@@ -0,0 +1,36 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewKeygrip(t *testing.T) {
+	// assert
+	assert.Panics(t, func() { NewKeygrip(nil, "") })
+	assert.Panics(t, func() { NewKeygrip([]string{ "key" }, "md5") })
+	assert.NotPanics(t, func() { NewKeygrip([]string{ "key" }, "sha512") })
+}
+
+func TestKeygrip_Sign(t *testing.T) {
+	// assert
+	assert.Equal(t, "EEFSxb_coHvGM-69RhmfAlXJ9J0", NewKeygrip([]string{ "key" }, "").Sign("data"))
+	assert.Equal(t, "UDH-PZicbRU3oBP6bnOdojRj_a7DtwE32Cjjas4iG9A", NewKeygrip([]string{ "key" }, "sha256").Sign("data"))
+	assert.Len(t, NewKeygrip([]string{ "key" }, "sha512").Sign("data"), 86)
+}
+
+func TestKeygrip_Index(t *testing.T) {
+	var keygrip *Keygrip
+	var signature string
+
+	// arrange
+	keygrip = NewKeygrip([]string{ "new key", "old key" }, "sha256")
+	signature = NewKeygrip([]string{ "old key" }, "sha256").Sign("data")
+
+	// assert
+	assert.Equal(t, 0, keygrip.Index("data", keygrip.Sign("data")))
+	assert.Equal(t, 1, keygrip.Index("data", signature))
+	assert.Equal(t, -1, keygrip.Index("other data", signature))
+	assert.True(t, keygrip.Verify("data", signature))
+	assert.False(t, keygrip.Verify("data", "invalid"))
+}
@@ -0,0 +1,146 @@
+package gokoa
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+// mimeTypesMutex guards mimeTypes against concurrent registration.
+var mimeTypesMutex sync.RWMutex
+
+// mimeTypes maps file extensions and shorthands to MIME types.
+var mimeTypes = map[string]string{
+	"bin":        "application/octet-stream",
+	"css":        "text/css",
+	"csv":        "text/csv",
+	"form":       "application/x-www-form-urlencoded",
+	"gif":        "image/gif",
+	"htm":        "text/html",
+	"html":       "text/html",
+	"ico":        "image/x-icon",
+	"jpeg":       "image/jpeg",
+	"jpg":        "image/jpeg",
+	"js":         "application/javascript",
+	"json":       "application/json",
+	"md":         "text/markdown",
+	"mp3":        "audio/mpeg",
+	"mp4":        "video/mp4",
+	"pdf":        "application/pdf",
+	"png":        "image/png",
+	"svg":        "image/svg+xml",
+	"text":       "text/plain",
+	"txt":        "text/plain",
+	"urlencoded": "application/x-www-form-urlencoded",
+	"wasm":       "application/wasm",
+	"webp":       "image/webp",
+	"xml":        "application/xml",
+	"zip":        "application/zip",
+}
+
+// utf8MimeTypes are MIME types, other than text/*, whose default
+// charset is UTF-8.
+var utf8MimeTypes = map[string]bool{
+	"application/javascript": true,
+	"application/json":       true,
+}
+
+// RegisterMimeType registers the given MIME type for the given file
+// extension or shorthand, which will be resolved by Response.SetType,
+// Request.Accepts and Request.Is, e.g.
+//
+//	gokoa.RegisterMimeType("yaml", "application/yaml")
+//
+// A registered extension overrides the built-in one. When the given
+// MIME type is NOT a text/* type, a charset parameter can be attached
+// to it for Response.SetType, e.g. "application/yaml; charset=utf-8".
+func RegisterMimeType(extension string, mimeType string) {
+	mimeTypesMutex.Lock()
+	defer mimeTypesMutex.Unlock()
+	mimeTypes[strings.ToLower(strings.TrimPrefix(extension, "."))] = mimeType
+}
+
+// contentType returns the full Content-Type header corresponding to
+// the given MIME type, file extension or shorthand, with the default
+// charset attached if there is one, e.g. "text/html; charset=utf-8"
+// for "html".
+//
+// contentType returns an empty string when the given type is unknown.
+func contentType(typ string) string {
+	mimeType := lookupMimeType(typ)
+	if mimeType == "" {
+		return ""
+	}
+	if strings.Contains(mimeType, "charset") {
+		return mimeType
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	if strings.HasPrefix(mediaType, "text/") || utf8MimeTypes[mediaType] {
+		return mimeType + "; charset=utf-8"
+	}
+	return mimeType
+}
+
+// lookupMimeType returns the MIME type corresponding to the given file
+// extension or shorthand, e.g. "json" or ".json".
+//
+// The given string is returned as it is when it already contains a
+// slash, and an empty string is returned when it is unknown.
+func lookupMimeType(extension string) string {
+	if strings.Contains(extension, "/") {
+		return extension
+	}
+
+	extension = strings.ToLower(strings.TrimPrefix(extension, "."))
+	mimeTypesMutex.RLock()
+	mimeType, ok := mimeTypes[extension]
+	mimeTypesMutex.RUnlock()
+	if ok {
+		return mimeType
+	}
+
+	if mimeType := mime.TypeByExtension("." + extension); mimeType != "" {
+		if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+			return mediaType
+		}
+	}
+	return ""
+}
+
+// normalizeMimeType returns the MIME type pattern corresponding to the
+// given type, which can be a MIME type, a MIME type pattern with
+// wildcards, a file extension, a shorthand, or a suffix like "+json".
+func normalizeMimeType(typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "+"):
+		return "*/*" + typ
+	case typ == "urlencoded":
+		return "application/x-www-form-urlencoded"
+	case typ == "multipart":
+		return "multipart/*"
+	}
+	return strings.TrimSpace(strings.SplitN(lookupMimeType(typ), ";", 2)[0])
+}
+
+// matchMimeType returns true when the given actual MIME type matches
+// the given expected MIME type pattern, where "*" matches any type or
+// subtype, and "*+suffix" matches any subtype with the suffix.
+func matchMimeType(expected string, actual string) bool {
+	expectedType, expectedSubtype := splitMediaType(expected)
+	actualType, actualSubtype := splitMediaType(actual)
+	if expectedSubtype == "" || actualSubtype == "" {
+		return false
+	}
+
+	if expectedType != "*" && !strings.EqualFold(expectedType, actualType) {
+		return false
+	}
+
+	if strings.HasPrefix(expectedSubtype, "*+") {
+		return len(actualSubtype) > len(expectedSubtype)-1 &&
+			strings.EqualFold(actualSubtype[len(actualSubtype)-len(expectedSubtype)+1:], expectedSubtype[1:])
+	}
+
+	return expectedSubtype == "*" || strings.EqualFold(expectedSubtype, actualSubtype)
+}
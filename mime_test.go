@@ -0,0 +1,65 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLookupMimeType(t *testing.T) {
+	// assert
+	assert.Equal(t, "application/json", lookupMimeType("json"))
+	assert.Equal(t, "application/json", lookupMimeType(".JSON"))
+	assert.Equal(t, "text/html", lookupMimeType("html"))
+	assert.Equal(t, "image/svg+xml", lookupMimeType("svg"))
+	assert.Equal(t, "text/plain; charset=utf-8", lookupMimeType("text/plain; charset=utf-8"))
+	assert.Equal(t, "", lookupMimeType("unknown"))
+	assert.Equal(t, "", lookupMimeType("multipart"))
+}
+
+func TestNormalizeMimeType(t *testing.T) {
+	// assert
+	assert.Equal(t, "*/*+json", normalizeMimeType("+json"))
+	assert.Equal(t, "application/x-www-form-urlencoded", normalizeMimeType("urlencoded"))
+	assert.Equal(t, "multipart/*", normalizeMimeType("multipart"))
+	assert.Equal(t, "application/json", normalizeMimeType("json"))
+	assert.Equal(t, "text/*", normalizeMimeType("text/*"))
+}
+
+func TestMatchMimeType(t *testing.T) {
+	// assert
+	assert.True(t, matchMimeType("application/json", "application/json"))
+	assert.True(t, matchMimeType("text/*", "text/html"))
+	assert.True(t, matchMimeType("*/*", "image/png"))
+	assert.True(t, matchMimeType("*/*+json", "application/vnd.api+json"))
+	assert.False(t, matchMimeType("*/*+json", "application/json"))
+	assert.False(t, matchMimeType("text/*", "application/json"))
+	assert.False(t, matchMimeType("", "application/json"))
+}
+
+func TestContentType(t *testing.T) {
+	// assert
+	assert.Equal(t, "text/html; charset=utf-8", contentType("html"))
+	assert.Equal(t, "text/plain; charset=utf-8", contentType(".txt"))
+	assert.Equal(t, "application/json; charset=utf-8", contentType("json"))
+	assert.Equal(t, "application/javascript; charset=utf-8", contentType("js"))
+	assert.Equal(t, "application/octet-stream", contentType("bin"))
+	assert.Equal(t, "image/png", contentType("image/png"))
+	assert.Equal(t, "text/html; charset=gbk", contentType("text/html; charset=gbk"))
+	assert.Equal(t, "", contentType("unknown"))
+}
+
+func TestRegisterMimeType(t *testing.T) {
+	// arrange
+	defer func() {
+		mimeTypesMutex.Lock()
+		delete(mimeTypes, "yaml")
+		mimeTypesMutex.Unlock()
+	}()
+
+	// act
+	RegisterMimeType(".YAML", "application/yaml; charset=utf-8")
+
+	// assert
+	assert.Equal(t, "application/yaml; charset=utf-8", contentType("yaml"))
+	assert.Equal(t, "application/yaml", normalizeMimeType("yaml"))
+}
@@ -0,0 +1,318 @@
+// Package multipart provides a middleware for gokoa, which parses
+// multipart/form-data request bodies and streams uploaded files to the
+// disk or to custom sinks, instead of keeping them in memory.
+//
+//	app.Use(multipart.New(multipart.Config{
+//		"maxFileSize": 100 << 20,
+//	}))
+//
+//	app.Use(func(ctx *gokoa.Context, next func() error) error {
+//		fields := ctx.Request.GetBody().(url.Values)
+//		avatar := ctx.Request.GetFile("avatar")
+//		...
+//	})
+//
+// Temporary files are removed after the response is sent.
+package multipart
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	mimemultipart "mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// A Config is a container which stores settings for configuring the
+// multipart parser.
+//
+// The Config is organized as key-value pairs, where the value is of
+// limited type.
+type Config map[string]interface{}
+
+// A Sink returns a writer, to which the content of the given uploaded
+// file will be written, instead of a temporary file.
+//
+// The writer will be closed after the content is written, even if an
+// error occurs.
+type Sink func(ctx *gokoa.Context, file *gokoa.File) (io.WriteCloser, error)
+
+var (
+	// errFileTooLarge is returned when an uploaded file is larger than
+	// the limit.
+	errFileTooLarge = errors.New("file too large")
+
+	// errTooManyFiles is returned when there are more uploaded files
+	// than the limit.
+	errTooManyFiles = errors.New("too many files")
+
+	// errFieldTooLarge is returned when a form field is larger than the
+	// limit.
+	errFieldTooLarge = errors.New("field too large")
+)
+
+// options are settings of the multipart parser.
+type options struct {
+	uploadDir    string
+	maxFileSize  int64
+	maxTotalSize int64
+	maxFiles     int
+	maxFieldSize int64
+	sink         Sink
+}
+
+// New returns a Middleware, which parses a multipart/form-data request
+// body before calling next, stores form fields by Request.SetBody as
+// an url.Values, and stores uploaded files by Request.SetFiles.
+//
+// The config can be nil, which causes the multipart parser to use
+// default configuration settings:
+//
+//	uploadDir     string  directory of temporary files, default to os.TempDir()
+//	maxFileSize   int     maximum of bytes in a file, default to 10 MiB
+//	maxTotalSize  int     maximum of bytes in the request body, default to 50 MiB
+//	maxFiles      int     maximum of files, default to 10
+//	maxFieldSize  int     maximum of bytes in a form field, default to 1 MiB
+//	sink          Sink    writer provider of files, default to nil
+//
+// When a Sink is provided, files are written to the Sink, and Path of
+// them will be empty.
+//
+// The returned Middleware responds with 413 Payload Too Large when any
+// limit is exceeded, and 400 Bad Request when the request body is
+// malformed, without calling next.
+//
+// Values in key-value pairs must be in the valid type, otherwise New
+// will panic.
+func New(config Config) gokoa.Middleware {
+	if config == nil {
+		config = make(Config)
+	}
+
+	opts := options{
+		uploadDir:    os.TempDir(),
+		maxFileSize:  10 << 20,
+		maxTotalSize: 50 << 20,
+		maxFiles:     10,
+		maxFieldSize: 1 << 20,
+	}
+
+	if uploadDir, ok := config["uploadDir"]; ok {
+		opts.uploadDir = uploadDir.(string)
+	}
+
+	if maxFileSize, ok := config["maxFileSize"]; ok {
+		opts.maxFileSize = int64(maxFileSize.(int))
+	}
+
+	if maxTotalSize, ok := config["maxTotalSize"]; ok {
+		opts.maxTotalSize = int64(maxTotalSize.(int))
+	}
+
+	if maxFiles, ok := config["maxFiles"]; ok {
+		opts.maxFiles = maxFiles.(int)
+	}
+
+	if maxFieldSize, ok := config["maxFieldSize"]; ok {
+		opts.maxFieldSize = int64(maxFieldSize.(int))
+	}
+
+	if sink, ok := config["sink"]; ok {
+		opts.sink = sink.(Sink)
+	}
+
+	return func(ctx *gokoa.Context, next func() error) error {
+		if ctx.Request.GetFiles() != nil {
+			return next()
+		}
+		if _, ok := ctx.Request.Is("multipart/form-data"); !ok {
+			return next()
+		}
+
+		fields, files, err := parse(ctx, opts)
+		if err != nil {
+			statusCode := http.StatusBadRequest
+			switch err {
+			case gokoa.ErrBodyTooLarge, errFileTooLarge, errTooManyFiles, errFieldTooLarge:
+				statusCode = http.StatusRequestEntityTooLarge
+			}
+			ctx.SetBody(err.Error())
+			ctx.SetStatus(statusCode)
+			return nil
+		}
+
+		ctx.Request.SetBody(fields)
+		ctx.Request.SetFiles(files)
+		return next()
+	}
+}
+
+// parse parses the multipart request body of the given Context, and
+// returns form fields and uploaded files.
+//
+// Temporary files are removed once an error occurs, otherwise they
+// are removed after the response is sent.
+func parse(ctx *gokoa.Context, opts options) (fields url.Values, files map[string][]*gokoa.File, err error) {
+	req := ctx.Request.Req
+	if opts.maxTotalSize > 0 && req.ContentLength > opts.maxTotalSize {
+		return nil, nil, gokoa.ErrBodyTooLarge
+	}
+	limited := &limitedReader{ReadCloser: req.Body, remaining: opts.maxTotalSize}
+	if opts.maxTotalSize > 0 {
+		req.Body = limited
+	}
+	wrapTooLarge := func(err error) error {
+		if limited.remaining < 0 {
+			return gokoa.ErrBodyTooLarge
+		}
+		return err
+	}
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, nil, wrapTooLarge(err)
+	}
+
+	fields = make(url.Values)
+	files = make(map[string][]*gokoa.File)
+	var paths []string
+	defer func() {
+		if err != nil {
+			removeAll(paths)
+		} else if len(paths) != 0 {
+			ctx.OnFinished(func() {
+				removeAll(paths)
+			})
+		}
+	}()
+
+	count := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, wrapTooLarge(err)
+		}
+
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(io.LimitReader(part, opts.maxFieldSize+1))
+			part.Close()
+			if err != nil {
+				return nil, nil, wrapTooLarge(err)
+			}
+			if int64(len(value)) > opts.maxFieldSize {
+				return nil, nil, errFieldTooLarge
+			}
+			fields.Add(name, string(value))
+			continue
+		}
+
+		count++
+		if opts.maxFiles > 0 && count > opts.maxFiles {
+			part.Close()
+			return nil, nil, errTooManyFiles
+		}
+
+		file := &gokoa.File{
+			FieldName: name,
+			Filename:  part.FileName(),
+			Header:    part.Header,
+		}
+		path, err := store(ctx, opts, part, file)
+		part.Close()
+		if path != "" {
+			paths = append(paths, path)
+		}
+		if err != nil {
+			return nil, nil, wrapTooLarge(err)
+		}
+		files[name] = append(files[name], file)
+	}
+
+	return fields, files, nil
+}
+
+// store writes the content of the given part to a temporary file or
+// the Sink, and returns the path of the temporary file if created.
+func store(ctx *gokoa.Context, opts options, part *mimemultipart.Part, file *gokoa.File) (string, error) {
+	var writer io.WriteCloser
+	var path string
+
+	if opts.sink != nil {
+		var err error
+		writer, err = opts.sink(ctx, file)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		temp, err := ioutil.TempFile(opts.uploadDir, "gokoa-upload-*")
+		if err != nil {
+			return "", err
+		}
+		writer = temp
+		path = temp.Name()
+		file.Path = path
+	}
+
+	var reader io.Reader = part
+	if opts.maxFileSize > 0 {
+		reader = io.LimitReader(part, opts.maxFileSize+1)
+	}
+	size, err := io.Copy(writer, reader)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return path, err
+	}
+	if opts.maxFileSize > 0 && size > opts.maxFileSize {
+		return path, errFileTooLarge
+	}
+
+	file.Size = size
+	return path, nil
+}
+
+// A limitedReader reads from the underlying reader, and returns
+// gokoa.ErrBodyTooLarge once more than the limit is read.
+type limitedReader struct {
+	io.ReadCloser
+
+	// remaining is the number of bytes allowed to be read.
+	remaining int64
+}
+
+// Read reads from the underlying reader.
+func (reader *limitedReader) Read(p []byte) (int, error) {
+	if reader.remaining < 0 {
+		return 0, gokoa.ErrBodyTooLarge
+	}
+	if int64(len(p)) > reader.remaining+1 {
+		p = p[:reader.remaining+1]
+	}
+	n, err := reader.ReadCloser.Read(p)
+	reader.remaining -= int64(n)
+	if reader.remaining < 0 {
+		return n, gokoa.ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// removeAll removes files at the given paths, ignoring errors.
+func removeAll(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
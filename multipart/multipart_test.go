@@ -0,0 +1,170 @@
+package multipart
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"io"
+	"io/ioutil"
+	mimemultipart "mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// nopWriteCloser is a bytes.Buffer with a no-op Close.
+type nopWriteCloser struct {
+	*bytes.Buffer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// newRequest returns a new multipart request with the given fields and
+// files, where files map field names to contents.
+func newRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := mimemultipart.NewWriter(&body)
+	for name, value := range fields {
+		assert.Nil(t, writer.WriteField(name, value))
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile(name, name+".txt")
+		assert.Nil(t, err)
+		_, err = io.WriteString(part, content)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestNew(t *testing.T) {
+	var app *gokoa.Application
+	var rec *httptest.ResponseRecorder
+	var res *http.Response
+	var fields interface{}
+	var file *gokoa.File
+	var content []byte
+	var err error
+
+	// arrange
+	app = gokoa.NewApplication(nil)
+	app.Use(New(nil)).Use(func(ctx *gokoa.Context, next func() error) error {
+		fields = ctx.Request.GetBody()
+		file = ctx.Request.GetFile("avatar")
+		content, err = ioutil.ReadFile(file.Path)
+		ctx.SetStatus(http.StatusOK)
+		return nil
+	})
+	rec = httptest.NewRecorder()
+
+	// act
+	app.Callback()(rec, newRequest(t, map[string]string{"name": "gokoa"}, map[string]string{"avatar": "hello gokoa"}))
+	res = rec.Result()
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, url.Values{"name": {"gokoa"}}, fields)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello gokoa"), content)
+	assert.Equal(t, "avatar", file.FieldName)
+	assert.Equal(t, "avatar.txt", file.Filename)
+	assert.Equal(t, int64(11), file.Size)
+	_, err = os.Stat(file.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNew_Sink(t *testing.T) {
+	var app *gokoa.Application
+	var rec *httptest.ResponseRecorder
+	var buffer *bytes.Buffer
+	var file *gokoa.File
+
+	// arrange
+	buffer = &bytes.Buffer{}
+	app = gokoa.NewApplication(nil)
+	app.Use(New(Config{
+		"sink": Sink(func(ctx *gokoa.Context, file *gokoa.File) (io.WriteCloser, error) {
+			return nopWriteCloser{buffer}, nil
+		}),
+	})).Use(func(ctx *gokoa.Context, next func() error) error {
+		file = ctx.Request.GetFile("avatar")
+		return nil
+	})
+	rec = httptest.NewRecorder()
+
+	// act
+	app.Callback()(rec, newRequest(t, nil, map[string]string{"avatar": "hello gokoa"}))
+
+	// assert
+	assert.Equal(t, "hello gokoa", buffer.String())
+	assert.Equal(t, "", file.Path)
+	assert.Equal(t, int64(11), file.Size)
+}
+
+func TestNew_Limits(t *testing.T) {
+	var dir string
+	var err error
+
+	// arrange
+	dir, err = ioutil.TempDir("", "gokoa")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		config Config
+		fields map[string]string
+		files  map[string]string
+	}{
+		{Config{"maxFileSize": 4}, nil, map[string]string{"avatar": "hello gokoa"}},
+		{Config{"maxFiles": 1}, nil, map[string]string{"a": "a", "b": "b"}},
+		{Config{"maxFieldSize": 4}, map[string]string{"name": "gokoa"}, nil},
+		{Config{"maxTotalSize": 64}, nil, map[string]string{"avatar": strings.Repeat("a", 128)}},
+	}
+
+	for _, c := range cases {
+		// arrange
+		c.config["uploadDir"] = dir
+		app := gokoa.NewApplication(nil)
+		app.Use(New(c.config))
+		rec := httptest.NewRecorder()
+		req := newRequest(t, c.fields, c.files)
+		req.ContentLength = -1
+
+		// act
+		app.Callback()(rec, req)
+		res := rec.Result()
+
+		// assert
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+		entries, err := ioutil.ReadDir(dir)
+		assert.Nil(t, err)
+		assert.Empty(t, entries)
+	}
+}
+
+func TestNew_Malformed(t *testing.T) {
+	var app *gokoa.Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+
+	// arrange
+	app = gokoa.NewApplication(nil)
+	app.Use(New(nil))
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("gokoa"))
+	req.Header.Set("Content-Type", "multipart/form-data")
+
+	// act
+	app.Callback()(rec, req)
+
+	// assert
+	assert.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+}
@@ -0,0 +1,229 @@
+package gokoa
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An acceptItem represents a single value in an Accept-like HTTP
+// request header, e.g. "text/html;q=0.8".
+type acceptItem struct {
+	// value is the value without parameters, e.g. "text/html".
+	value string
+
+	// params are parameters except for "q".
+	params map[string]string
+
+	// q is the quality of the value, ranging from 0 to 1.
+	q float64
+
+	// index is the position of the value in the header.
+	index int
+}
+
+// A priority represents how much a provided value is preferred by the
+// client.
+type priority struct {
+	// value is the provided value.
+	value string
+
+	// q is the quality of the best matching acceptItem.
+	q float64
+
+	// specificity is how specifically the best matching acceptItem
+	// matches, the bigger the more specific.
+	specificity int
+
+	// order is the index of the best matching acceptItem.
+	order int
+
+	// index is the position of the provided value.
+	index int
+}
+
+// A specifier returns the specificity of the given acceptItem matching
+// the given provided value, or -1 when they do NOT match.
+type specifier func(provided string, item acceptItem) int
+
+// parseAccept parses the given Accept-like HTTP request header into
+// acceptItems, discarding empty values.
+func parseAccept(header string) []acceptItem {
+	var items []acceptItem
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		value := strings.TrimSpace(fields[0])
+		if value == "" {
+			continue
+		}
+
+		item := acceptItem{
+			value:  value,
+			params: make(map[string]string),
+			q:      1,
+			index:  len(items),
+		}
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(kv[0]))
+			value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			if key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					item.q = q
+				}
+			} else {
+				item.params[key] = value
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// negotiate returns the provided values acceptable by the given
+// acceptItems, sorted from the most preferred to the least.
+//
+// When no value is provided, values in acceptItems with positive
+// qualities are returned in the order of preference.
+func negotiate(items []acceptItem, provided []string, specify specifier) []string {
+	var priorities []priority
+
+	if len(provided) == 0 {
+		for _, item := range items {
+			if item.q > 0 {
+				priorities = append(priorities, priority{value: item.value, q: item.q, order: item.index})
+			}
+		}
+	} else {
+		for i, value := range provided {
+			best := priority{value: value, specificity: -1, index: i}
+			for _, item := range items {
+				specificity := specify(value, item)
+				if specificity < 0 {
+					continue
+				}
+				if specificity > best.specificity ||
+					(specificity == best.specificity && item.q > best.q) ||
+					(specificity == best.specificity && item.q == best.q && item.index < best.order) {
+					best.q = item.q
+					best.specificity = specificity
+					best.order = item.index
+				}
+			}
+			if best.specificity >= 0 && best.q > 0 {
+				priorities = append(priorities, best)
+			}
+		}
+	}
+
+	sort.SliceStable(priorities, func(i, j int) bool {
+		a, b := priorities[i], priorities[j]
+		if a.q != b.q {
+			return a.q > b.q
+		}
+		if a.specificity != b.specificity {
+			return a.specificity > b.specificity
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		return a.index < b.index
+	})
+
+	values := make([]string, len(priorities))
+	for i, p := range priorities {
+		values[i] = p.value
+	}
+	return values
+}
+
+// specifyMediaType is the specifier for the Accept header, where the
+// provided value can be either a MIME type or a shorthand of it.
+func specifyMediaType(provided string, item acceptItem) int {
+	mimeType := lookupMimeType(provided)
+	if mimeType == "" {
+		return -1
+	}
+
+	params := make(map[string]string)
+	if i := strings.Index(mimeType, ";"); i != -1 {
+		for _, field := range strings.Split(mimeType[i+1:], ";") {
+			if kv := strings.SplitN(field, "=", 2); len(kv) == 2 {
+				params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+			}
+		}
+		mimeType = mimeType[:i]
+	}
+
+	typ, subtype := splitMediaType(mimeType)
+	acceptedType, acceptedSubtype := splitMediaType(item.value)
+
+	specificity := 0
+	if strings.EqualFold(acceptedType, typ) {
+		specificity |= 4
+	} else if acceptedType != "*" {
+		return -1
+	}
+	if strings.EqualFold(acceptedSubtype, subtype) {
+		specificity |= 2
+	} else if acceptedSubtype != "*" && subtype != "*" {
+		return -1
+	}
+	if len(item.params) != 0 {
+		for key, value := range item.params {
+			if !strings.EqualFold(params[key], value) {
+				return -1
+			}
+		}
+		specificity |= 1
+	}
+	return specificity
+}
+
+// splitMediaType splits the given MIME type into its type and subtype.
+func splitMediaType(mimeType string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(mimeType), "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// specifyToken is the specifier for the Accept-Encoding and the
+// Accept-Charset headers, where values are case insensitive tokens.
+func specifyToken(provided string, item acceptItem) int {
+	if strings.EqualFold(item.value, provided) {
+		return 1
+	}
+	if item.value == "*" {
+		return 0
+	}
+	return -1
+}
+
+// specifyLanguage is the specifier for the Accept-Language header,
+// where a language matches its prefix and vice versa, e.g. "en"
+// matches "en-US".
+func specifyLanguage(provided string, item acceptItem) int {
+	prefix := func(language string) string {
+		return strings.SplitN(language, "-", 2)[0]
+	}
+
+	switch {
+	case strings.EqualFold(item.value, provided):
+		return 4
+	case strings.EqualFold(prefix(item.value), provided):
+		return 2
+	case strings.EqualFold(item.value, prefix(provided)):
+		return 1
+	case item.value == "*":
+		return 0
+	}
+	return -1
+}
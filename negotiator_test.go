@@ -0,0 +1,30 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	var items []acceptItem
+
+	// act
+	items = parseAccept(`text/html;level=1;q=0.5, , application/json;charset="utf-8"`)
+
+	// assert
+	assert.Equal(t, []acceptItem{
+		{ value: "text/html", params: map[string]string{ "level": "1" }, q: 0.5, index: 0 },
+		{ value: "application/json", params: map[string]string{ "charset": "utf-8" }, q: 1, index: 1 },
+	}, items)
+}
+
+func TestNegotiate(t *testing.T) {
+	var items []acceptItem
+
+	// arrange
+	items = parseAccept("text/*, text/html;q=0.2, */*;q=0.1")
+
+	// assert
+	assert.Equal(t, []string{ "text/plain", "text/html", "image/png" }, negotiate(items, []string{ "text/html", "image/png", "text/plain" }, specifyMediaType))
+	assert.Equal(t, []string{ "text/*", "text/html", "*/*" }, negotiate(items, nil, specifyMediaType))
+}
@@ -1,7 +1,18 @@
 package gokoa
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
 )
 
 // A Request represents a HTTP request received by the Application.
@@ -12,8 +23,53 @@ type Request struct {
 	response *Response
 	ctx *Context
 	app *Application
+
+	// originalURL is the request URL before being rewritten by any
+	// middleware.
+	originalURL string
+
+	// body is the parsed request body, e.g. by a body parser.
+	body interface{}
+
+	// rawBody is the request body read by ReadBody.
+	rawBody []byte
+
+	// files are files uploaded in the request body, grouped by field
+	// names.
+	files map[string][]*File
 }
 
+// A File represents a file uploaded in a multipart request body.
+type File struct {
+	// FieldName is the name of the form field.
+	FieldName string
+
+	// Filename is the file name provided by the client.
+	Filename string
+
+	// Header is the MIME header of the part, e.g. Content-Type.
+	Header textproto.MIMEHeader
+
+	// Size is the size of the file in bytes.
+	Size int64
+
+	// Path is the path of the file stored on the disk, or an empty
+	// string when the file is NOT stored on the disk.
+	Path string
+}
+
+// Open opens the file stored on the disk for reading.
+func (file *File) Open() (*os.File, error) {
+	if file.Path == "" {
+		return nil, errors.New("file is not stored on the disk")
+	}
+	return os.Open(file.Path)
+}
+
+// ErrBodyTooLarge is returned when the request body is larger than
+// the limit.
+var ErrBodyTooLarge = errors.New("request body too large")
+
 // NewRequest returns a new empty Request.
 func NewRequest() *Request {
 	return &Request{}
@@ -23,3 +79,619 @@ func NewRequest() *Request {
 func (request *Request) GetMethod() string {
 	return request.Req.Method
 }
+
+// GetURL returns the request URL, which consists of the path and the
+// query string, e.g. "/users?page=1".
+func (request *Request) GetURL() string {
+	return request.Req.URL.RequestURI()
+}
+
+// SetURL rewrites the request URL with the given string, which is
+// useful for URL rewriting.
+//
+// SetURL returns an error when the given string is NOT a valid
+// request URL.
+func (request *Request) SetURL(rawurl string) error {
+	u, err := url.ParseRequestURI(rawurl)
+	if err != nil {
+		return err
+	}
+
+	request.Req.URL.Path = u.Path
+	request.Req.URL.RawPath = u.RawPath
+	request.Req.URL.RawQuery = u.RawQuery
+	request.Req.RequestURI = request.Req.URL.RequestURI()
+	return nil
+}
+
+// GetOriginalURL returns the request URL before being rewritten by
+// any middleware.
+func (request *Request) GetOriginalURL() string {
+	return request.originalURL
+}
+
+// GetPath returns the unescaped path of the request URL.
+func (request *Request) GetPath() string {
+	return request.Req.URL.Path
+}
+
+// SetPath assigns the given unescaped path to the request URL, while
+// the query string is kept.
+func (request *Request) SetPath(path string) {
+	request.Req.URL.Path = path
+	request.Req.URL.RawPath = ""
+	request.Req.RequestURI = request.Req.URL.RequestURI()
+}
+
+// GetQuery returns the parsed query string of the request URL.
+//
+// Malformed key-value pairs in the query string are discarded.
+func (request *Request) GetQuery() url.Values {
+	return request.Req.URL.Query()
+}
+
+// GetQueryMap returns the parsed query string of the request URL as a
+// map, keeping only the first value of each key.
+func (request *Request) GetQueryMap() map[string]string {
+	query := make(map[string]string)
+	for key, values := range request.GetQuery() {
+		if len(values) != 0 {
+			query[key] = values[0]
+		}
+	}
+	return query
+}
+
+// SetQuery assigns the given values to the query string of the
+// request URL.
+func (request *Request) SetQuery(query url.Values) {
+	request.SetQuerystring(query.Encode())
+}
+
+// GetQuerystring returns the raw query string of the request URL
+// without the leading "?".
+func (request *Request) GetQuerystring() string {
+	return request.Req.URL.RawQuery
+}
+
+// SetQuerystring assigns the given raw string to the query string of
+// the request URL.
+func (request *Request) SetQuerystring(querystring string) {
+	request.Req.URL.RawQuery = querystring
+	request.Req.RequestURI = request.Req.URL.RequestURI()
+}
+
+// GetSearch returns the raw query string of the request URL with the
+// leading "?", or an empty string when there is no query string.
+func (request *Request) GetSearch() string {
+	if request.Req.URL.RawQuery == "" {
+		return ""
+	}
+	return "?" + request.Req.URL.RawQuery
+}
+
+// SetSearch assigns the given raw string to the query string of the
+// request URL, the leading "?" of which is optional.
+func (request *Request) SetSearch(search string) {
+	request.SetQuerystring(strings.TrimPrefix(search, "?"))
+}
+
+// absoluteURLRegexp matches URLs with HTTP or HTTPS scheme.
+var absoluteURLRegexp = regexp.MustCompile(`(?i)^https?://`)
+
+// GetHref returns the full request URL, which consists of the origin
+// and the original request URL.
+func (request *Request) GetHref() string {
+	if absoluteURLRegexp.MatchString(request.originalURL) {
+		return request.originalURL
+	}
+	return request.GetOrigin() + request.originalURL
+}
+
+// GetOrigin returns the origin of the request URL, which consists of
+// the protocol and the host, e.g. "https://example.com:8080".
+func (request *Request) GetOrigin() string {
+	return request.GetProtocol() + "://" + request.GetHost()
+}
+
+// GetHost returns the host of the request, which consists of the
+// hostname and the port if present.
+//
+// When the proxy is trusted, the X-Forwarded-Host header takes
+// precedence.
+func (request *Request) GetHost() string {
+	host := ""
+	if request.isProxyTrusted() {
+		host = request.Req.Header.Get("X-Forwarded-Host")
+	}
+	if host == "" {
+		host = request.Req.Host
+	}
+	return strings.TrimSpace(strings.SplitN(host, ",", 2)[0])
+}
+
+// GetHostname returns the hostname of the request, without the port.
+//
+// Brackets around an IPv6 address are kept, e.g. "[::1]".
+func (request *Request) GetHostname() string {
+	host := request.GetHost()
+	if host == "" {
+		return ""
+	}
+	if strings.HasPrefix(host, "[") {
+		if i := strings.Index(host, "]"); i != -1 {
+			return host[:i+1]
+		}
+		return host
+	}
+	if i := strings.Index(host, ":"); i != -1 {
+		return host[:i]
+	}
+	return host
+}
+
+// GetSubdomains returns subdomains of the request hostname in reverse
+// order, ignoring the rightmost SubdomainOffset labels of the
+// Application.
+//
+// For example, with the default offset 2, subdomains of
+// "tobi.ferrets.example.com" are ["ferrets", "tobi"].
+//
+// GetSubdomains returns an empty array when the hostname is an IP
+// address.
+func (request *Request) GetSubdomains() []string {
+	subdomains := []string{}
+
+	hostname := strings.Trim(request.GetHostname(), "[]")
+	if hostname == "" || net.ParseIP(hostname) != nil {
+		return subdomains
+	}
+
+	labels := strings.Split(hostname, ".")
+	offset := 0
+	if request.app != nil {
+		offset = request.app.SubdomainOffset
+	}
+	for i := len(labels) - 1 - offset; i >= 0; i-- {
+		subdomains = append(subdomains, labels[i])
+	}
+	return subdomains
+}
+
+// GetProtocol returns the protocol of the request, which is either
+// "https" or "http".
+//
+// When the proxy is trusted, the X-Forwarded-Proto header is used for
+// requests NOT over a TLS connection.
+func (request *Request) GetProtocol() string {
+	if request.Req.TLS != nil {
+		return "https"
+	}
+	if !request.isProxyTrusted() {
+		return "http"
+	}
+
+	proto := request.Req.Header.Get("X-Forwarded-Proto")
+	if proto == "" {
+		return "http"
+	}
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(proto, ",", 2)[0]))
+}
+
+// IsSecure returns true when the protocol of the request is "https".
+func (request *Request) IsSecure() bool {
+	return request.GetProtocol() == "https"
+}
+
+// GetIPs returns IP addresses in the proxy IP header, from the client
+// to the nearest proxy, when the proxy is trusted, otherwise returns
+// an empty array.
+//
+// At most maxIpsCount addresses nearest to the Application are
+// returned when maxIpsCount of the Application is positive.
+func (request *Request) GetIPs() []string {
+	ips := []string{}
+	if !request.isProxyTrusted() {
+		return ips
+	}
+
+	for _, value := range request.Req.Header[http.CanonicalHeaderKey(request.app.proxyIpHeader)] {
+		for _, ip := range strings.Split(value, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				ips = append(ips, ip)
+			}
+		}
+	}
+
+	if request.app.maxIpsCount > 0 && len(ips) > request.app.maxIpsCount {
+		ips = ips[len(ips)-request.app.maxIpsCount:]
+	}
+	return ips
+}
+
+// GetIP returns the IP address of the client, which is the first one
+// in GetIPs, or the remote address of the connection when GetIPs is
+// empty.
+func (request *Request) GetIP() string {
+	if ips := request.GetIPs(); len(ips) != 0 {
+		return ips[0]
+	}
+	return request.remoteIP()
+}
+
+// remoteIP returns the IP address of the remote end of the
+// connection, without the port.
+func (request *Request) remoteIP() string {
+	host, _, err := net.SplitHostPort(request.Req.RemoteAddr)
+	if err != nil {
+		return request.Req.RemoteAddr
+	}
+	return host
+}
+
+// isProxyTrusted returns true when proxy headers of the request
+// should be trusted according to the Application.
+func (request *Request) isProxyTrusted() bool {
+	return request.app != nil && request.app.isProxyTrusted(request.remoteIP())
+}
+
+// Accepts returns the type most preferred by the client among the
+// given types according to the Accept header, and true when any of
+// them is acceptable.
+//
+// Types can be either MIME types or shorthands of them, e.g.
+// "application/json", "json" or ".json", and the matched one is
+// returned as it is given.
+//
+//	// Accept: text/*, application/json
+//	request.Accepts("html")          // "html", true
+//	request.Accepts("json", "text")  // "json", true
+//	request.Accepts("image/png")     // "", false
+//
+// When no type is given, Accepts returns the type most preferred by
+// the client.
+func (request *Request) Accepts(types ...string) (string, bool) {
+	header := request.Req.Header.Get("Accept")
+	if header == "" {
+		header = "*/*"
+	}
+	return first(negotiate(parseAccept(header), types, specifyMediaType))
+}
+
+// AcceptsEncodings returns the encoding most preferred by the client
+// among the given encodings according to the Accept-Encoding header,
+// and true when any of them is acceptable.
+//
+// The "identity" encoding is acceptable unless it is explicitly
+// refused by the client.
+func (request *Request) AcceptsEncodings(encodings ...string) (string, bool) {
+	items := parseAccept(request.Req.Header.Get("Accept-Encoding"))
+
+	identity := false
+	minQ := 1.0
+	for _, item := range items {
+		if strings.EqualFold(item.value, "identity") || item.value == "*" {
+			identity = true
+		}
+		if item.q < minQ {
+			minQ = item.q
+		}
+	}
+	if !identity {
+		items = append(items, acceptItem{value: "identity", q: minQ, index: len(items)})
+	}
+
+	return first(negotiate(items, encodings, specifyToken))
+}
+
+// AcceptsCharsets returns the charset most preferred by the client
+// among the given charsets according to the Accept-Charset header, and
+// true when any of them is acceptable.
+func (request *Request) AcceptsCharsets(charsets ...string) (string, bool) {
+	header := request.Req.Header.Get("Accept-Charset")
+	if header == "" {
+		header = "*"
+	}
+	return first(negotiate(parseAccept(header), charsets, specifyToken))
+}
+
+// AcceptsLanguages returns the language most preferred by the client
+// among the given languages according to the Accept-Language header,
+// and true when any of them is acceptable.
+func (request *Request) AcceptsLanguages(languages ...string) (string, bool) {
+	header := request.Req.Header.Get("Accept-Language")
+	if header == "" {
+		header = "*"
+	}
+	return first(negotiate(parseAccept(header), languages, specifyLanguage))
+}
+
+// first returns the first string in the given array, and true when the
+// array is NOT empty.
+func first(values []string) (string, bool) {
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// HasBody returns true when the request has a body, which is
+// indicated by either the Transfer-Encoding or the Content-Length
+// header, even if the length is 0.
+func (request *Request) HasBody() bool {
+	return len(request.Req.TransferEncoding) != 0 ||
+		request.Req.ContentLength != 0 ||
+		request.Req.Header.Get("Content-Length") != ""
+}
+
+// GetLength returns the length of the request body in bytes, or -1
+// when it is unknown.
+func (request *Request) GetLength() int {
+	if !request.HasBody() {
+		return -1
+	}
+	return int(request.Req.ContentLength)
+}
+
+// GetType returns the MIME type in the Content-Type header without
+// parameters, e.g. "application/json".
+func (request *Request) GetType() string {
+	mediaType, _, err := mime.ParseMediaType(request.Req.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// GetCharset returns the charset parameter in the Content-Type
+// header, or an empty string when it is NOT present.
+func (request *Request) GetCharset() string {
+	_, params, err := mime.ParseMediaType(request.Req.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// Is returns the first type among the given types matching the
+// Content-Type header of the request, and true when any of them
+// matches.
+//
+// Types can be MIME types, MIME types with wildcards, file extensions
+// or shorthands, e.g. "application/json", "json", "text/*",
+// "+json", "urlencoded" or "multipart". A shorthand is returned as it
+// is given, while the actual MIME type is returned for a type with
+// wildcards or a suffix.
+//
+//	// Content-Type: application/json; charset=utf-8
+//	request.Is("json", "urlencoded") // "json", true
+//	request.Is("application/*")      // "application/json", true
+//	request.Is("html")               // "", false
+//
+// When no type is given, Is returns the MIME type of the request.
+//
+// Is returns "" and false when the request has no body, use HasBody
+// to tell this case apart from a mismatch.
+func (request *Request) Is(types ...string) (string, bool) {
+	if !request.HasBody() {
+		return "", false
+	}
+
+	actual := request.GetType()
+	if actual == "" {
+		return "", false
+	}
+
+	if len(types) == 0 {
+		return actual, true
+	}
+
+	for _, typ := range types {
+		if !matchMimeType(normalizeMimeType(typ), actual) {
+			continue
+		}
+		if strings.HasPrefix(typ, "+") || strings.Contains(typ, "*") {
+			return actual, true
+		}
+		return typ, true
+	}
+	return "", false
+}
+
+// noCacheRegexp matches the no-cache directive in the Cache-Control
+// header.
+var noCacheRegexp = regexp.MustCompile(`(?:^|,)\s*no-cache\s*(?:,|$)`)
+
+// IsFresh returns true when the response is still fresh in the cache
+// of the client, according to RFC 7232, which means that the client
+// can reuse its cached response.
+//
+// The If-None-Match header is compared with the ETag header of the
+// response using the weak comparison, and the If-Modified-Since header
+// is compared with the Last-Modified header of the response only when
+// there is no If-None-Match header.
+//
+// IsFresh always returns false for requests of methods other than GET
+// and HEAD, or for responses with status codes other than 2xx and 304.
+func (request *Request) IsFresh() bool {
+	method := request.GetMethod()
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+
+	statusCode := request.response.GetStatus()
+	if (statusCode < 200 || statusCode >= 300) && statusCode != http.StatusNotModified {
+		return false
+	}
+
+	noneMatch := request.Req.Header.Get("If-None-Match")
+	modifiedSince := request.Req.Header.Get("If-Modified-Since")
+	if noneMatch == "" && modifiedSince == "" {
+		return false
+	}
+
+	if noCacheRegexp.MatchString(request.Req.Header.Get("Cache-Control")) {
+		return false
+	}
+
+	if noneMatch != "" {
+		if strings.TrimSpace(noneMatch) == "*" {
+			return true
+		}
+
+		etag := strings.TrimPrefix(request.response.GetEtag(), "W/")
+		if etag == "" {
+			return false
+		}
+		for _, match := range strings.Split(noneMatch, ",") {
+			if strings.TrimPrefix(strings.TrimSpace(match), "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(modifiedSince)
+	if err != nil {
+		return false
+	}
+	lastModified := request.response.GetLastModified()
+	return !lastModified.IsZero() && !lastModified.After(since)
+}
+
+// IsStale returns the opposite of IsFresh.
+func (request *Request) IsStale() bool {
+	return !request.IsFresh()
+}
+
+// GetBody returns the parsed request body, or nil when the request
+// body is NOT parsed yet.
+func (request *Request) GetBody() interface{} {
+	return request.body
+}
+
+// SetBody assigns the given object to the parsed request body.
+func (request *Request) SetBody(body interface{}) {
+	request.body = body
+}
+
+// GetRawBody returns the request body read by ReadBody, or nil when
+// the request body is NOT read yet.
+func (request *Request) GetRawBody() []byte {
+	return request.rawBody
+}
+
+// ReadBody reads the whole request body and returns it.
+//
+// The request body can only be read from the connection once, so
+// ReadBody keeps it and returns the kept one in later calls.
+//
+// The limit is the maximum of bytes to be read, where 0 means
+// infinity. ReadBody returns ErrBodyTooLarge when the request body is
+// larger than the limit.
+func (request *Request) ReadBody(limit int) ([]byte, error) {
+	if request.rawBody != nil {
+		if limit > 0 && len(request.rawBody) > limit {
+			return nil, ErrBodyTooLarge
+		}
+		return request.rawBody, nil
+	}
+
+	if limit > 0 && request.Req.ContentLength > int64(limit) {
+		return nil, ErrBodyTooLarge
+	}
+
+	if request.Req.Body == nil {
+		request.rawBody = []byte{}
+		return request.rawBody, nil
+	}
+
+	var reader io.Reader = request.Req.Body
+	if limit > 0 {
+		reader = io.LimitReader(reader, int64(limit)+1)
+	}
+	raw, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(raw) > limit {
+		return nil, ErrBodyTooLarge
+	}
+
+	request.rawBody = raw
+	return raw, nil
+}
+
+// ReadText reads the whole request body by ReadBody, and decodes it
+// into a UTF-8 string according to the charset in the Content-Type
+// header.
+//
+// ReadText returns ErrUnsupportedCharset when the charset can NOT be
+// decoded.
+func (request *Request) ReadText(limit int) (string, error) {
+	raw, err := request.ReadBody(limit)
+	if err != nil {
+		return "", err
+	}
+	return decodeCharset(raw, request.GetCharset())
+}
+
+// ReadJSON reads the whole request body by ReadText, and decodes it as
+// JSON into the given object.
+func (request *Request) ReadJSON(v interface{}, limit int) error {
+	text, err := request.ReadText(limit)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(text), v)
+}
+
+// ReadForm reads the whole request body by ReadText, and parses it as
+// an URL encoded form.
+func (request *Request) ReadForm(limit int) (url.Values, error) {
+	text, err := request.ReadText(limit)
+	if err != nil {
+		return nil, err
+	}
+	return url.ParseQuery(text)
+}
+
+// GetFiles returns files uploaded in the request body, grouped by
+// field names, or nil when the request body is NOT parsed as a
+// multipart form yet.
+func (request *Request) GetFiles() map[string][]*File {
+	return request.files
+}
+
+// GetFile returns the first file uploaded in the request body with the
+// given field name, or nil when there is no such file.
+func (request *Request) GetFile(fieldName string) *File {
+	if files := request.files[fieldName]; len(files) != 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// SetFiles assigns the given files to files uploaded in the request
+// body.
+func (request *Request) SetFiles(files map[string][]*File) {
+	request.files = files
+}
+
+// Get returns the value corresponding to the key in the HTTP request
+// header.
+//
+// The "Referer" and "Referrer" keys are interchangeable.
+func (request *Request) Get(field string) string {
+	switch strings.ToLower(field) {
+	case "referer", "referrer":
+		return request.Req.Referer()
+	}
+	return request.Req.Header.Get(field)
+}
+
+// GetHeaders returns a copy of the HTTP request header, modifying
+// which does NOT affect the HTTP request.
+func (request *Request) GetHeaders() http.Header {
+	return request.Req.Header.Clone()
+}
@@ -4,7 +4,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewRequest(t *testing.T) {
@@ -18,6 +21,9 @@ var request *Request
 	assert.Equal(t, (*Response)(nil), request.response)
 	assert.Equal(t, (*Context)(nil), request.ctx)
 	assert.Equal(t, (*Application)(nil), request.app)
+	assert.Equal(t, "", request.originalURL)
+	assert.Equal(t, nil, request.body)
+	assert.Equal(t, []byte(nil), request.rawBody)
 }
 
 func TestRequest_GetMethod(t *testing.T) {
@@ -46,3 +52,617 @@ func TestRequest_GetMethod(t *testing.T) {
 	// assert
 	assert.Equal(t, http.MethodPost, method)
 }
+
+func TestRequest_URL(t *testing.T) {
+	var app *Application
+	var rec *httptest.ResponseRecorder
+	var req *http.Request
+	var mux *http.ServeMux
+	var before string
+	var after string
+	var original string
+	var path string
+	var err error
+
+	// arrange
+	app = NewApplication(nil)
+	app.middlewares = []Middleware {
+		func(ctx *Context, next func() error) error {
+			before = ctx.Request.GetURL()
+			err = ctx.Request.SetURL("/rewritten?b=2")
+			return next()
+		},
+		func(ctx *Context, next func() error) error {
+			after = ctx.Request.GetURL()
+			original = ctx.Request.GetOriginalURL()
+			path = ctx.Request.GetPath()
+			return nil
+		},
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/users?a=1", nil)
+	mux = http.NewServeMux()
+
+	// act
+	mux.HandleFunc("/", app.Callback())
+	mux.ServeHTTP(rec, req)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/users?a=1", before)
+	assert.Equal(t, "/rewritten?b=2", after)
+	assert.Equal(t, "/users?a=1", original)
+	assert.Equal(t, "/rewritten", path)
+}
+
+func TestRequest_Path(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/users/a%2Fb?page=1", nil)
+
+	// assert
+	assert.Equal(t, "/users/a/b", request.GetPath())
+
+	// act
+	request.SetPath("/posts/hello world")
+
+	// assert
+	assert.Equal(t, "/posts/hello world", request.GetPath())
+	assert.Equal(t, "/posts/hello%20world?page=1", request.GetURL())
+}
+
+func TestRequest_Query(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/?a=1&a=2&b=3", nil)
+
+	// assert
+	assert.Equal(t, url.Values{"a": {"1", "2"}, "b": {"3"}}, request.GetQuery())
+	assert.Equal(t, map[string]string{"a": "1", "b": "3"}, request.GetQueryMap())
+	assert.Equal(t, "a=1&a=2&b=3", request.GetQuerystring())
+	assert.Equal(t, "?a=1&a=2&b=3", request.GetSearch())
+
+	// act
+	request.SetQuery(url.Values{"c": {"4"}})
+
+	// assert
+	assert.Equal(t, "c=4", request.GetQuerystring())
+	assert.Equal(t, "/?c=4", request.GetURL())
+
+	// act
+	request.SetSearch("?d=5")
+
+	// assert
+	assert.Equal(t, "d=5", request.GetQuerystring())
+
+	// act
+	request.SetQuerystring("")
+
+	// assert
+	assert.Equal(t, "", request.GetSearch())
+	assert.Equal(t, "/", request.GetURL())
+}
+
+func TestRequest_Origin(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/users?a=1", nil)
+	request.Req.Host = "example.com:8080"
+	request.originalURL = "/users?a=1"
+
+	// assert
+	assert.Equal(t, "example.com:8080", request.GetHost())
+	assert.Equal(t, "example.com", request.GetHostname())
+	assert.Equal(t, "http://example.com:8080", request.GetOrigin())
+	assert.Equal(t, "http://example.com:8080/users?a=1", request.GetHref())
+
+	// arrange
+	request.Req = httptest.NewRequest(http.MethodGet, "https://[::1]:8443/", nil)
+	request.originalURL = "https://[::1]:8443/"
+
+	// assert
+	assert.Equal(t, "[::1]", request.GetHostname())
+	assert.Equal(t, "https://[::1]:8443", request.GetOrigin())
+	assert.Equal(t, "https://[::1]:8443/", request.GetHref())
+}
+
+func TestRequest_IP(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+	request.app = NewApplication(nil)
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Req.RemoteAddr = "10.0.0.1:1234"
+	request.Req.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	request.Req.Header.Add("X-Forwarded-For", "3.3.3.3")
+
+	// assert
+	assert.Equal(t, []string{}, request.GetIPs())
+	assert.Equal(t, "10.0.0.1", request.GetIP())
+
+	// arrange
+	request.app = NewApplication(ApplicationConfig{ "proxy": true })
+
+	// assert
+	assert.Equal(t, []string{ "1.1.1.1", "2.2.2.2", "3.3.3.3" }, request.GetIPs())
+	assert.Equal(t, "1.1.1.1", request.GetIP())
+
+	// arrange
+	request.app = NewApplication(ApplicationConfig{ "proxy": true, "maxIpsCount": 2 })
+
+	// assert
+	assert.Equal(t, []string{ "2.2.2.2", "3.3.3.3" }, request.GetIPs())
+	assert.Equal(t, "2.2.2.2", request.GetIP())
+
+	// arrange
+	request.app = NewApplication(ApplicationConfig{ "proxy": true, "proxyIpHeader": "X-Real-IP" })
+	request.Req.Header.Set("X-Real-IP", "4.4.4.4")
+
+	// assert
+	assert.Equal(t, "4.4.4.4", request.GetIP())
+
+	// arrange
+	request.app = NewApplication(ApplicationConfig{ "proxy": true, "trustedProxies": []string{ "192.168.0.0/16" } })
+
+	// assert
+	assert.Equal(t, "10.0.0.1", request.GetIP())
+
+	// arrange
+	request.app = NewApplication(ApplicationConfig{ "proxy": true, "trustedProxies": []string{ "10.0.0.0/8" } })
+
+	// assert
+	assert.Equal(t, "1.1.1.1", request.GetIP())
+}
+
+func TestRequest_Protocol(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+	request.app = NewApplication(nil)
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Req.Header.Set("X-Forwarded-Proto", "https, http")
+	request.Req.Header.Set("X-Forwarded-Host", "example.com, proxy.local")
+
+	// assert
+	assert.Equal(t, "http", request.GetProtocol())
+	assert.Equal(t, false, request.IsSecure())
+	assert.Equal(t, "example.com", request.GetHost())
+
+	// arrange
+	request.app = NewApplication(ApplicationConfig{ "proxy": true })
+
+	// assert
+	assert.Equal(t, "https", request.GetProtocol())
+	assert.Equal(t, true, request.IsSecure())
+	assert.Equal(t, "example.com", request.GetHost())
+	assert.Equal(t, "https://example.com", request.GetOrigin())
+
+	// arrange
+	request.Req = httptest.NewRequest(http.MethodGet, "https://secure.local/", nil)
+
+	// assert
+	assert.Equal(t, "https", request.GetProtocol())
+	assert.Equal(t, "secure.local", request.GetHost())
+}
+
+func TestRequest_GetSubdomains(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+	request.app = NewApplication(nil)
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Req.Host = "tobi.ferrets.example.com:8080"
+
+	// assert
+	assert.Equal(t, []string{ "ferrets", "tobi" }, request.GetSubdomains())
+
+	// arrange
+	request.app.SubdomainOffset = 3
+
+	// assert
+	assert.Equal(t, []string{ "tobi" }, request.GetSubdomains())
+
+	// arrange
+	request.app.SubdomainOffset = 5
+
+	// assert
+	assert.Equal(t, []string{}, request.GetSubdomains())
+
+	// arrange
+	request.app.SubdomainOffset = 2
+	request.Req.Host = "127.0.0.1:8080"
+
+	// assert
+	assert.Equal(t, []string{}, request.GetSubdomains())
+
+	// arrange
+	request.Req.Host = "[::1]:8080"
+
+	// assert
+	assert.Equal(t, []string{}, request.GetSubdomains())
+}
+
+func TestRequest_Accepts(t *testing.T) {
+	var request *Request
+	var accepted string
+	var ok bool
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	accepted, ok = request.Accepts("json", "html")
+
+	// assert
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "json", accepted)
+
+	// arrange
+	request.Req.Header.Set("Accept", "text/*;q=0.5, application/json, text/csv")
+
+	// assert
+	accepted, ok = request.Accepts("html", "csv")
+	assert.Equal(t, "csv", accepted)
+	accepted, ok = request.Accepts("html", "json")
+	assert.Equal(t, "json", accepted)
+	accepted, ok = request.Accepts("text/html")
+	assert.Equal(t, "text/html", accepted)
+	accepted, ok = request.Accepts("png", "unknown")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, "", accepted)
+	accepted, ok = request.Accepts()
+	assert.Equal(t, "application/json", accepted)
+
+	// arrange
+	request.Req.Header.Set("Accept", "application/json;q=0, */*")
+
+	// assert
+	accepted, ok = request.Accepts("json")
+	assert.Equal(t, false, ok)
+	accepted, ok = request.Accepts("json", "xml")
+	assert.Equal(t, "xml", accepted)
+}
+
+func TestRequest_AcceptsEncodings(t *testing.T) {
+	var request *Request
+	var accepted string
+	var ok bool
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// assert
+	accepted, ok = request.AcceptsEncodings("gzip", "identity")
+	assert.Equal(t, "identity", accepted)
+	accepted, ok = request.AcceptsEncodings("gzip")
+	assert.Equal(t, false, ok)
+
+	// arrange
+	request.Req.Header.Set("Accept-Encoding", "gzip;q=0.8, br")
+
+	// assert
+	accepted, ok = request.AcceptsEncodings("gzip", "br")
+	assert.Equal(t, "br", accepted)
+	accepted, ok = request.AcceptsEncodings("deflate", "identity")
+	assert.Equal(t, "identity", accepted)
+
+	// arrange
+	request.Req.Header.Set("Accept-Encoding", "gzip, identity;q=0")
+
+	// assert
+	accepted, ok = request.AcceptsEncodings("identity")
+	assert.Equal(t, false, ok)
+}
+
+func TestRequest_AcceptsCharsets(t *testing.T) {
+	var request *Request
+	var accepted string
+	var ok bool
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// assert
+	accepted, ok = request.AcceptsCharsets("utf-8")
+	assert.Equal(t, "utf-8", accepted)
+
+	// arrange
+	request.Req.Header.Set("Accept-Charset", "ISO-8859-1;q=0.5, UTF-8")
+
+	// assert
+	accepted, ok = request.AcceptsCharsets("iso-8859-1", "utf-8")
+	assert.Equal(t, "utf-8", accepted)
+	accepted, ok = request.AcceptsCharsets("gbk")
+	assert.Equal(t, false, ok)
+}
+
+func TestRequest_AcceptsLanguages(t *testing.T) {
+	var request *Request
+	var accepted string
+	var ok bool
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Req.Header.Set("Accept-Language", "zh-CN, en;q=0.8")
+
+	// assert
+	accepted, ok = request.AcceptsLanguages("en", "zh-CN")
+	assert.Equal(t, "zh-CN", accepted)
+	accepted, ok = request.AcceptsLanguages("en-US", "fr")
+	assert.Equal(t, "en-US", accepted)
+	accepted, ok = request.AcceptsLanguages("zh", "en")
+	assert.Equal(t, "zh", accepted)
+	accepted, ok = request.AcceptsLanguages("fr")
+	assert.Equal(t, false, ok)
+	accepted, ok = request.AcceptsLanguages()
+	assert.Equal(t, "zh-CN", accepted)
+}
+
+func TestRequest_Is(t *testing.T) {
+	var request *Request
+	var matched string
+	var ok bool
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodPost, "/", nil)
+	request.Req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	// assert
+	assert.Equal(t, false, request.HasBody())
+	assert.Equal(t, -1, request.GetLength())
+	matched, ok = request.Is("json")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, "", matched)
+
+	// arrange
+	request.Req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	request.Req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	// assert
+	assert.Equal(t, true, request.HasBody())
+	assert.Equal(t, 7, request.GetLength())
+	assert.Equal(t, "application/json", request.GetType())
+	assert.Equal(t, "UTF-8", request.GetCharset())
+	matched, ok = request.Is("urlencoded", "json")
+	assert.Equal(t, "json", matched)
+	matched, ok = request.Is("application/*")
+	assert.Equal(t, "application/json", matched)
+	matched, ok = request.Is()
+	assert.Equal(t, "application/json", matched)
+	matched, ok = request.Is("html", "multipart")
+	assert.Equal(t, false, ok)
+
+	// arrange
+	request.Req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+
+	// assert
+	matched, ok = request.Is("multipart/*")
+	assert.Equal(t, "multipart/form-data", matched)
+	matched, ok = request.Is("multipart")
+	assert.Equal(t, "multipart", matched)
+	assert.Equal(t, "", request.GetCharset())
+}
+
+func TestRequest_IsFresh(t *testing.T) {
+	var request *Request
+	var response *Response
+	var lastModified time.Time
+
+	// arrange
+	lastModified = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	response.SetStatus(http.StatusOK)
+	response.SetEtag("abc")
+	request = NewRequest()
+	request.response = response
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+	assert.Equal(t, true, request.IsStale())
+
+	// arrange
+	request.Req.Header.Set("If-None-Match", `"xyz", W/"abc"`)
+
+	// assert
+	assert.Equal(t, true, request.IsFresh())
+
+	// arrange
+	request.Req.Header.Set("Cache-Control", "max-age=0, no-cache")
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+
+	// arrange
+	request.Req.Header.Del("Cache-Control")
+	request.Req.Header.Set("If-None-Match", `"xyz"`)
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+
+	// arrange
+	request.Req.Header.Del("If-None-Match")
+	request.Req.Header.Set("If-Modified-Since", lastModified.Format(http.TimeFormat))
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+
+	// arrange
+	response.SetLastModified(lastModified)
+
+	// assert
+	assert.Equal(t, true, request.IsFresh())
+
+	// arrange
+	response.SetLastModified(lastModified.Add(time.Second))
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+
+	// arrange
+	response.SetLastModified(lastModified)
+	response.SetStatus(http.StatusInternalServerError)
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+
+	// arrange
+	response.SetStatus(http.StatusOK)
+	request.Req.Method = http.MethodPost
+
+	// assert
+	assert.Equal(t, false, request.IsFresh())
+}
+
+func TestRequest_ReadBody(t *testing.T) {
+	var request *Request
+	var raw []byte
+	var err error
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello gokoa"))
+
+	// act
+	_, err = request.ReadBody(5)
+
+	// assert
+	assert.Equal(t, ErrBodyTooLarge, err)
+	assert.Equal(t, []byte(nil), request.GetRawBody())
+
+	// act
+	raw, err = request.ReadBody(0)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello gokoa"), raw)
+	assert.Equal(t, []byte("hello gokoa"), request.GetRawBody())
+
+	// act
+	raw, err = request.ReadBody(11)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello gokoa"), raw)
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello gokoa"))
+	request.Req.ContentLength = -1
+
+	// act
+	_, err = request.ReadBody(5)
+
+	// assert
+	assert.Equal(t, ErrBodyTooLarge, err)
+}
+
+func TestRequest_ReadJSON(t *testing.T) {
+	var request *Request
+	var body map[string]interface{}
+	var err error
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{\"name\":\"caf\xE9\"}"))
+	request.Req.Header.Set("Content-Type", "application/json; charset=latin1")
+
+	// act
+	err = request.ReadJSON(&body, 0)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{ "name": "café" }, body)
+}
+
+func TestRequest_ReadForm(t *testing.T) {
+	var request *Request
+	var form url.Values
+	var err error
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=1&a=2&b=%E4%B8%96"))
+	request.Req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// act
+	form, err = request.ReadForm(0)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{ "a": { "1", "2" }, "b": { "世" } }, form)
+}
+
+func TestRequest_Body(t *testing.T) {
+	var request *Request
+
+	// arrange
+	request = NewRequest()
+
+	// act
+	request.SetBody("hello gokoa")
+
+	// assert
+	assert.Equal(t, "hello gokoa", request.GetBody())
+}
+
+func TestRequest_Files(t *testing.T) {
+	var request *Request
+	var file *File
+
+	// arrange
+	request = NewRequest()
+	file = &File{ FieldName: "avatar", Filename: "avatar.png" }
+
+	// assert
+	assert.Equal(t, (*File)(nil), request.GetFile("avatar"))
+
+	// act
+	request.SetFiles(map[string][]*File{ "avatar": { file } })
+
+	// assert
+	assert.Equal(t, map[string][]*File{ "avatar": { file } }, request.GetFiles())
+	assert.Equal(t, file, request.GetFile("avatar"))
+	assert.Equal(t, (*File)(nil), request.GetFile("missing"))
+	_, err := file.Open()
+	assert.NotNil(t, err)
+}
+
+func TestRequest_Get(t *testing.T) {
+	var request *Request
+	var headers http.Header
+
+	// arrange
+	request = NewRequest()
+	request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Req.Header.Set("Referer", "http://localhost/")
+	request.Req.Header.Set("X-Foo", "bar")
+
+	// assert
+	assert.Equal(t, "bar", request.Get("x-foo"))
+	assert.Equal(t, "http://localhost/", request.Get("Referer"))
+	assert.Equal(t, "http://localhost/", request.Get("Referrer"))
+	assert.Equal(t, "", request.Get("X-Bar"))
+
+	// act
+	headers = request.GetHeaders()
+	headers.Set("X-Foo", "baz")
+
+	// assert
+	assert.Equal(t, "bar", request.Get("X-Foo"))
+}
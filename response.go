@@ -2,11 +2,16 @@ package gokoa
 
 import (
 	"encoding/json"
+	"fmt"
+	"html"
 	"io"
-	"io/ioutil"
 	"net/http"
+	"os"
+	"path"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // A Response represents a HTTP response sent back by the Application.
@@ -28,6 +33,18 @@ type Response struct {
 	// body represents the HTTP response body that will be sent back to
 	// the client, default to an empty byte array.
 	body []byte
+
+	// stream represents the HTTP response body that will be streamed
+	// to the client, which is exclusive with body.
+	stream io.Reader
+
+	// fileInfo describes the file from which the HTTP response body is
+	// read, or nil when the body is NOT read from a file.
+	fileInfo os.FileInfo
+
+	// finished is true when the HTTP response is sent by the
+	// Application.
+	finished bool
 }
 
 // NewResponse returns a new empty Response.
@@ -43,23 +60,100 @@ func (response *Response) GetStatus() int {
 }
 
 // SetStatus assigns the given integer to the HTTP status code.
-func (response *Response) SetStatus(statusCode int) {
+//
+// SetStatus returns ErrHeaderSent when the HTTP response header is
+// sent.
+func (response *Response) SetStatus(statusCode int) error {
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
 	// TODO: validate whether the given integer is a valid HTTP status code
 	response.statusCode = statusCode
+	return nil
+}
+
+// HeaderSent returns true when the HTTP response header is sent to the
+// client, e.g. by FlushHeaders, after which the HTTP response status
+// code and header can NOT be modified.
+func (response *Response) HeaderSent() bool {
+	if res, ok := response.Res.(*responseWriter); ok {
+		return res.headerSent()
+	}
+	return false
 }
 
-// GetBody returns the HTTP response body.
+// Writable returns true when the HTTP response body can still be
+// written, i.e. the HTTP response is NOT finished, the connection is
+// NOT hijacked and the client is still connected.
+func (response *Response) Writable() bool {
+	if response.finished {
+		return false
+	}
+	if res, ok := response.Res.(*responseWriter); ok && res.hijacked {
+		return false
+	}
+	if response.request != nil && response.request.Req != nil {
+		return response.request.Req.Context().Err() == nil
+	}
+	return true
+}
+
+// FlushHeaders sends the HTTP response header with the current status
+// code to the client immediately, which is useful for long polling or
+// server-sent events, e.g.
+//
+//	ctx.SetStatus(http.StatusOK)
+//	ctx.Response.Set("Content-Type", "text/event-stream")
+//	ctx.Response.FlushHeaders()
+//
+// After that, the HTTP response body can be written to Res directly.
+// FlushHeaders does nothing when the HTTP response header is sent.
+func (response *Response) FlushHeaders() {
+	if response.HeaderSent() {
+		return
+	}
+
+	response.Res.WriteHeader(response.statusCode)
+	if flusher, ok := response.Res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// GetBody returns the HTTP response body, or nil when the body is a
+// stream.
 func (response *Response) GetBody() []byte {
 	return response.body
 }
 
+// GetStream returns the HTTP response body when it is a stream, or nil
+// otherwise.
+func (response *Response) GetStream() io.Reader {
+	return response.stream
+}
+
 // SetBody assigns the given object to the HTTP response body.
 //
 // The given object can be either a string, a byte array, an
-// io.Reader, or a map containing key-value pairs. Whenever what type
-// the given object is, it will be transformed into a byte array.
+// io.Reader, or any other value which will be encoded into JSON by the
+// JSONEncoder of the Application. An io.Reader is kept as a stream,
+// which will be copied to the client without being read into memory,
+// while the others will be transformed into a byte array.
+//
+// The Content-Length header is set for a stream when its length is
+// known, i.e. a regular file or a reader with a Len method like
+// *bytes.Reader. A stream implementing io.Closer will be closed after
+// the HTTP response is sent, even if it is replaced or NOT sent.
+//
+// SetBody returns ErrHeaderSent when the HTTP response header is sent.
 func (response *Response) SetBody(body interface{}) error {
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
 	var bytes []byte
+	response.fileInfo = nil
+	response.stream = nil
 
 	if body == nil {
 		bytes = nil
@@ -97,24 +191,41 @@ func (response *Response) SetBody(body interface{}) error {
 			}
 			response.SetLength(len(bytes))
 		case io.Reader:
-			var err error
-			bytes, err = ioutil.ReadAll(body)
-			if err != nil {
-				return err
+			if file, ok := body.(interface{ Stat() (os.FileInfo, error) }); ok {
+				if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+					response.fileInfo = info
+				}
 			}
 
+			if closer, ok := body.(io.Closer); ok && response.ctx != nil {
+				response.ctx.OnFinished(func() {
+					closer.Close()
+				})
+			}
+			response.stream = body
+
 			if !typeSet {
 				response.SetType("bin")
 			}
-			response.Remove("Content-Length")
-		case map[string]interface{}:
+			if length, ok := response.streamLength(); ok {
+				response.SetLength(length)
+			} else {
+				response.Remove("Content-Length")
+			}
+		default:
+			encode := json.Marshal
+			if response.app != nil && response.app.JSONEncoder != nil {
+				encode = response.app.JSONEncoder
+			}
+
 			var err error
-			bytes, err = json.Marshal(body)
+			bytes, err = encode(body)
 			if err != nil {
 				return err
 			}
 
 			response.SetType("json")
+			response.SetLength(len(bytes))
 		}
 	}
 
@@ -122,25 +233,251 @@ func (response *Response) SetBody(body interface{}) error {
 	return nil
 }
 
-// GetLength returns the HTTP response Content-Length header.
-func (response *Response) GetLength() int {
-	if length, err := strconv.Atoi(response.Get("Content-Length")); err != nil {
-		panic(err)
+// Redirect redirects the client to the given URL by setting the
+// Location header, with a small HTML or text body depending on what
+// the client accepts.
+//
+// The status code is set to 302 Found, unless a 3xx status code has
+// been set before, e.g.
+//
+//	ctx.SetStatus(http.StatusMovedPermanently)
+//	ctx.Redirect("/login")
+//
+// The URL can be "back", which redirects the client to the Referer
+// header, or to the given alternative URL when the Referer header is
+// absent, or "/" when the alternative URL is NOT given either.
+func (response *Response) Redirect(url string, alt ...string) error {
+	if url == "back" {
+		url = ""
+		if response.request != nil {
+			url = response.request.Req.Referer()
+		}
+		if url == "" && len(alt) > 0 {
+			url = alt[0]
+		}
+		if url == "" {
+			url = "/"
+		}
+	}
+
+	statusCode := response.statusCode
+	if statusCode < http.StatusMultipleChoices || statusCode >= http.StatusBadRequest {
+		statusCode = http.StatusFound
+	}
+
+	if err := response.Set("Location", url); err != nil {
+		return err
+	}
+
+	var body string
+	if response.acceptsHTML() {
+		response.SetType("html")
+		body = fmt.Sprintf(`Redirecting to <a href="%s">%s</a>.`, html.EscapeString(url), html.EscapeString(url))
 	} else {
-		return length
+		response.SetType("text")
+		body = fmt.Sprintf("Redirecting to %s.", url)
+	}
+	if err := response.SetBody(body); err != nil {
+		return err
+	}
+
+	response.SetStatus(statusCode)
+	return nil
+}
+
+// acceptsHTML returns true when the client prefers HTML to plain
+// text.
+func (response *Response) acceptsHTML() bool {
+	if response.request == nil || response.request.Req == nil {
+		return false
+	}
+	accepted, _ := response.request.Accepts("html", "text")
+	return accepted == "html"
+}
+
+// An AttachmentOptions is a container which stores settings for
+// Response.Attachment, organized as key-value pairs.
+//
+// The available keys are "type", which is either "attachment" (by
+// default) or "inline", and "fallback", which is the ASCII filename
+// used by clients that do NOT support the filename* parameter.
+type AttachmentOptions map[string]interface{}
+
+// Attachment sets the Content-Disposition header to prompt the client
+// to download the HTTP response body, as described in RFC 6266.
+//
+// When a filename is given, only its base name is used, and the
+// Content-Type header is set according to its extension. A filename
+// containing non-ASCII characters, e.g. "报表.csv", is encoded into the
+// filename* parameter, with an ASCII fallback in the filename
+// parameter where non-ASCII characters are replaced by "?".
+//
+// Attachment returns ErrHeaderSent when the HTTP response header is
+// sent.
+func (response *Response) Attachment(filename string, opts AttachmentOptions) error {
+	if opts == nil {
+		opts = make(AttachmentOptions)
+	}
+
+	dispositionType := "attachment"
+	if typ, ok := opts["type"]; ok {
+		dispositionType = typ.(string)
+	}
+
+	if filename == "" {
+		return response.Set("Content-Disposition", dispositionType)
+	}
+
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if extension := path.Ext(filename); contentType(extension) != "" {
+		if err := response.SetType(extension); err != nil {
+			return err
+		}
+	}
+
+	var fallback string
+	if value, ok := opts["fallback"]; ok {
+		fallback = toASCII(value.(string))
+	} else {
+		fallback = toASCII(filename)
+	}
+
+	disposition := dispositionType + `; filename="` + quoteEscaper.Replace(fallback) + `"`
+	if fallback != filename {
+		disposition += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return response.Set("Content-Disposition", disposition)
+}
+
+// quoteEscaper escapes a string to be quoted in a HTTP header.
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// toASCII replaces non-ASCII and control characters in the given
+// string with "?".
+func toASCII(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		if r < 0x20 || r >= 0x7f {
+			builder.WriteByte('?')
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// encodeRFC5987 percent-encodes the given string except attr-chars,
+// as described in RFC 5987.
+func encodeRFC5987(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			builder.WriteByte(c)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", c)
+		}
+	}
+	return builder.String()
+}
+
+// GetFileInfo returns the information of the file from which the HTTP
+// response body is read, e.g. an *os.File, or nil when the body is NOT
+// read from a regular file.
+func (response *Response) GetFileInfo() os.FileInfo {
+	return response.fileInfo
+}
+
+// streamLength returns the number of bytes remaining in the stream,
+// and true when it is known.
+func (response *Response) streamLength() (int, bool) {
+	if reader, ok := response.stream.(interface{ Len() int }); ok {
+		return reader.Len(), true
+	}
+
+	if response.fileInfo != nil {
+		if seeker, ok := response.stream.(io.Seeker); ok {
+			if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+				return int(response.fileInfo.Size() - offset), true
+			}
+		}
 	}
+
+	return 0, false
+}
+
+// GetLength returns the HTTP response Content-Length header, or -1
+// when it is absent or invalid.
+func (response *Response) GetLength() int {
+	length, err := strconv.Atoi(response.Get("Content-Length"))
+	if err != nil {
+		return -1
+	}
+	return length
 }
 
 // SetLength assigns the given integer to the HTTP response
 // Content-Length header.
-func (response *Response) SetLength(length int) {
-	response.Set("Content-Length", strconv.Itoa(length))
+//
+// SetLength returns ErrHeaderSent when the HTTP response header is
+// sent. The same applies to the other header setters.
+func (response *Response) SetLength(length int) error {
+	return response.Set("Content-Length", strconv.Itoa(length))
 }
 
-// SetType assigns the given string to the HTTP response Content-Type
-// header.
-func (response *Response) SetType(contentType string) {
-	response.Set("Content-Type", contentType)
+// GetType returns the MIME type in the HTTP response Content-Type
+// header without parameters, e.g. "text/html".
+func (response *Response) GetType() string {
+	return strings.TrimSpace(strings.SplitN(response.Get("Content-Type"), ";", 2)[0])
+}
+
+// SetType assigns the MIME type corresponding to the given string to
+// the HTTP response Content-Type header, with the default charset
+// attached if there is one.
+//
+// The given string can be a MIME type, a file extension or a
+// shorthand, e.g. "text/html", ".html" or "html", all of which result
+// in "text/html; charset=utf-8". The Content-Type header is removed
+// when the given string is unknown.
+//
+// Extensions and shorthands can be extended by RegisterMimeType.
+func (response *Response) SetType(typ string) error {
+	if value := contentType(typ); value != "" {
+		return response.Set("Content-Type", value)
+	}
+	return response.Remove("Content-Type")
+}
+
+// GetEtag returns the HTTP response ETag header.
+func (response *Response) GetEtag() string {
+	return response.Get("ETag")
+}
+
+// SetEtag assigns the given string to the HTTP response ETag header,
+// which will be quoted if it is NOT quoted yet, e.g. abc becomes
+// "abc" while W/"abc" is kept.
+func (response *Response) SetEtag(etag string) error {
+	if !strings.HasPrefix(etag, "\"") && !strings.HasPrefix(etag, "W/\"") {
+		etag = "\"" + etag + "\""
+	}
+	return response.Set("ETag", etag)
+}
+
+// GetLastModified returns the HTTP response Last-Modified header, or
+// the zero time when it is absent or invalid.
+func (response *Response) GetLastModified() time.Time {
+	lastModified, err := http.ParseTime(response.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return lastModified
+}
+
+// SetLastModified assigns the given time to the HTTP response
+// Last-Modified header.
+func (response *Response) SetLastModified(lastModified time.Time) error {
+	return response.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
 }
 
 // Get returns the value corresponding to the key in the HTTP response
@@ -155,12 +492,112 @@ func (response *Response) Has(field string) bool {
 	return response.Get(field) != ""
 }
 
-// Set assigns the key-value pair to the HTTP response header.
-func (response *Response) Set(field string, value string) {
-	response.Res.Header().Set(field, value)
+// Set assigns the key-value pair to the HTTP response header,
+// replacing any existing values.
+//
+// The given value can be either a string, a string array for multiple
+// values, or any other value which will be formatted into a string.
+//
+// Set returns ErrHeaderSent when the HTTP response header is sent.
+func (response *Response) Set(field string, value interface{}) error {
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
+	switch value := value.(type) {
+	case string:
+		response.Res.Header().Set(field, value)
+	case []string:
+		response.Res.Header()[http.CanonicalHeaderKey(field)] = append([]string(nil), value...)
+	default:
+		response.Res.Header().Set(field, fmt.Sprint(value))
+	}
+	return nil
+}
+
+// Append appends the given values to the HTTP response header,
+// keeping any existing values, e.g.
+//
+//	response.Append("Link", "<http://localhost/>", "<http://localhost:3000/>")
+//
+// Append returns ErrHeaderSent when the HTTP response header is sent.
+func (response *Response) Append(field string, values ...string) error {
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
+	for _, value := range values {
+		response.Res.Header().Add(field, value)
+	}
+	return nil
+}
+
+// Vary merges the given fields into the HTTP response Vary header
+// without duplicates, which is case-insensitive.
+//
+// The Vary header is set to "*" when any field is "*", and no more
+// field will be merged into it.
+//
+// Vary returns ErrHeaderSent when the HTTP response header is sent.
+func (response *Response) Vary(fields ...string) error {
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
+	var vary []string
+	for _, value := range response.Res.Header()["Vary"] {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				vary = append(vary, field)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if field == "*" {
+			vary = []string{"*"}
+			break
+		}
+		if len(vary) == 1 && vary[0] == "*" {
+			break
+		}
+
+		exist := false
+		for _, value := range vary {
+			if strings.EqualFold(value, field) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			vary = append(vary, field)
+		}
+	}
+
+	if len(vary) == 0 {
+		return nil
+	}
+	return response.Set("Vary", strings.Join(vary, ", "))
+}
+
+// GetHeaders returns a copy of the HTTP response header, modifying
+// which does NOT affect the HTTP response.
+func (response *Response) GetHeaders() http.Header {
+	return response.Res.Header().Clone()
 }
 
 // Remove deletes the key-value pair from the HTTP response header.
-func (response *Response) Remove(field string) {
+//
+// Remove returns ErrHeaderSent when the HTTP response header is sent.
+func (response *Response) Remove(field string) error {
+	if response.HeaderSent() {
+		return ErrHeaderSent
+	}
+
 	response.Res.Header().Del(field)
+	return nil
 }
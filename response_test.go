@@ -2,9 +2,13 @@ package gokoa
 
 import (
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewResponse(t *testing.T) {
@@ -20,6 +24,8 @@ func TestNewResponse(t *testing.T) {
 	assert.Equal(t, (*Application)(nil), response.app)
 	assert.Equal(t, http.StatusNotFound, response.statusCode)
 	assert.Equal(t, []byte(nil), response.body)
+	assert.Equal(t, os.FileInfo(nil), response.fileInfo)
+	assert.Equal(t, nil, response.stream)
 }
 
 func TestResponse_GetStatus(t *testing.T) {
@@ -103,3 +109,450 @@ func TestResponse_GetBody(t *testing.T) {
 	// assert
 	assert.Equal(t, []byte(nil), body)
 }
+
+func TestResponse_Etag(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+
+	// act
+	response.SetEtag("abc")
+
+	// assert
+	assert.Equal(t, `"abc"`, response.GetEtag())
+
+	// act
+	response.SetEtag(`W/"abc"`)
+
+	// assert
+	assert.Equal(t, `W/"abc"`, response.GetEtag())
+}
+
+func TestResponse_Type(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+
+	// act
+	response.SetType("html")
+
+	// assert
+	assert.Equal(t, "text/html; charset=utf-8", response.Get("Content-Type"))
+	assert.Equal(t, "text/html", response.GetType())
+
+	// act
+	response.SetType(".json")
+
+	// assert
+	assert.Equal(t, "application/json; charset=utf-8", response.Get("Content-Type"))
+	assert.Equal(t, "application/json", response.GetType())
+
+	// act
+	response.SetType("image/png")
+
+	// assert
+	assert.Equal(t, "image/png", response.Get("Content-Type"))
+
+	// act
+	response.SetType("unknown")
+
+	// assert
+	assert.False(t, response.Has("Content-Type"))
+	assert.Equal(t, "", response.GetType())
+
+	// arrange
+	response.SetType("html")
+
+	// act
+	response.SetType("multipart")
+
+	// assert
+	assert.False(t, response.Has("Content-Type"))
+}
+
+func TestResponse_LastModified(t *testing.T) {
+	var response *Response
+	var lastModified time.Time
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	lastModified = time.Date(2020, 6, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*60*60))
+
+	// assert
+	assert.True(t, response.GetLastModified().IsZero())
+
+	// act
+	response.SetLastModified(lastModified)
+
+	// assert
+	assert.Equal(t, "Mon, 01 Jun 2020 00:00:00 GMT", response.Get("Last-Modified"))
+	assert.True(t, lastModified.Equal(response.GetLastModified()))
+}
+
+func TestResponse_GetFileInfo(t *testing.T) {
+	var response *Response
+	var file *os.File
+	var err error
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	file, err = ioutil.TempFile("", "gokoa")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	_, err = file.WriteString("hello gokoa")
+	assert.Nil(t, err)
+	_, err = file.Seek(0, 0)
+	assert.Nil(t, err)
+
+	// act
+	err = response.SetBody(file)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), response.GetFileInfo().Size())
+	assert.Equal(t, file, response.GetStream())
+
+	// act
+	err = response.SetBody(strings.NewReader("hello gokoa"))
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileInfo(nil), response.GetFileInfo())
+}
+
+func TestResponse_SetBody_Stream(t *testing.T) {
+	var response *Response
+	var file *os.File
+	var err error
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	file, err = ioutil.TempFile("", "gokoa")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	_, err = file.WriteString("hello gokoa")
+	assert.Nil(t, err)
+	_, err = file.Seek(6, 0)
+	assert.Nil(t, err)
+
+	// act
+	err = response.SetBody(file)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(nil), response.GetBody())
+	assert.Equal(t, 5, response.GetLength())
+
+	// act
+	err = response.SetBody(strings.NewReader("hello gokoa"))
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, 11, response.GetLength())
+
+	// act
+	err = response.SetBody(ioutil.NopCloser(strings.NewReader("hello gokoa")))
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, -1, response.GetLength())
+
+	// act
+	err = response.SetBody("hello gokoa")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, nil, response.GetStream())
+	assert.Equal(t, []byte("hello gokoa"), response.GetBody())
+}
+
+func TestResponse_SetBody_JSON(t *testing.T) {
+	var response *Response
+	var err error
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+
+	// act
+	err = response.SetBody(struct {
+		Name string `json:"name"`
+	}{ Name: "gokoa" })
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"name":"gokoa"}`), response.GetBody())
+	assert.Equal(t, 16, response.GetLength())
+
+	// act
+	err = response.SetBody([]map[string]interface{}{ { "name": "gokoa" } })
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`[{"name":"gokoa"}]`), response.GetBody())
+
+	// act
+	err = response.SetBody(make(chan int))
+
+	// assert
+	assert.NotNil(t, err)
+
+	// arrange
+	response.app = NewApplication(nil)
+	response.app.JSONEncoder = IndentJSONEncoder("", "  ")
+
+	// act
+	err = response.SetBody([]int{ 1 })
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("[\n  1\n]"), response.GetBody())
+}
+
+func TestResponse_Redirect(t *testing.T) {
+	var response *Response
+	var err error
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	response.request = NewRequest()
+	response.request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+	response.request.Req.Header.Set("Accept", "text/html")
+
+	// act
+	err = response.Redirect("/login?next=<a>")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, response.GetStatus())
+	assert.Equal(t, "/login?next=<a>", response.Get("Location"))
+	assert.Equal(t, "text/html; charset=utf-8", response.Get("Content-Type"))
+	assert.Equal(t, `Redirecting to <a href="/login?next=&lt;a&gt;">/login?next=&lt;a&gt;</a>.`, string(response.GetBody()))
+
+	// arrange
+	response.request.Req.Header.Set("Accept", "text/plain")
+	response.SetStatus(http.StatusMovedPermanently)
+
+	// act
+	err = response.Redirect("/users")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusMovedPermanently, response.GetStatus())
+	assert.Equal(t, "text/plain; charset=utf-8", response.Get("Content-Type"))
+	assert.Equal(t, "Redirecting to /users.", string(response.GetBody()))
+}
+
+func TestResponse_Redirect_Back(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	response.request = NewRequest()
+	response.request.Req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// act
+	response.Redirect("back", "/index.html")
+
+	// assert
+	assert.Equal(t, "/index.html", response.Get("Location"))
+
+	// act
+	response.Redirect("back")
+
+	// assert
+	assert.Equal(t, "/", response.Get("Location"))
+
+	// arrange
+	response.request.Req.Header.Set("Referer", "/previous")
+
+	// act
+	response.Redirect("back", "/index.html")
+
+	// assert
+	assert.Equal(t, "/previous", response.Get("Location"))
+	assert.Equal(t, http.StatusFound, response.GetStatus())
+}
+
+func TestResponse_Attachment(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+
+	// act
+	response.Attachment("", nil)
+
+	// assert
+	assert.Equal(t, "attachment", response.Get("Content-Disposition"))
+	assert.False(t, response.Has("Content-Type"))
+
+	// act
+	response.Attachment("path/to/report.pdf", nil)
+
+	// assert
+	assert.Equal(t, `attachment; filename="report.pdf"`, response.Get("Content-Disposition"))
+	assert.Equal(t, "application/pdf", response.Get("Content-Type"))
+
+	// act
+	response.Attachment(`say "hi".txt`, AttachmentOptions{ "type": "inline" })
+
+	// assert
+	assert.Equal(t, `inline; filename="say \"hi\".txt"`, response.Get("Content-Disposition"))
+	assert.Equal(t, "text/plain; charset=utf-8", response.Get("Content-Type"))
+
+	// act
+	response.Attachment("报表 2020.csv", nil)
+
+	// assert
+	assert.Equal(t, `attachment; filename="?? 2020.csv"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8%202020.csv`, response.Get("Content-Disposition"))
+	assert.Equal(t, "text/csv; charset=utf-8", response.Get("Content-Type"))
+
+	// act
+	response.Attachment("报表.csv", AttachmentOptions{ "fallback": "report.csv" })
+
+	// assert
+	assert.Equal(t, `attachment; filename="report.csv"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.csv`, response.Get("Content-Disposition"))
+}
+
+func TestResponse_Set(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+
+	// act
+	response.Set("X-Foo", "bar")
+
+	// assert
+	assert.Equal(t, []string{ "bar" }, response.Res.Header()["X-Foo"])
+
+	// act
+	response.Set("x-foo", []string{ "a", "b" })
+
+	// assert
+	assert.Equal(t, []string{ "a", "b" }, response.Res.Header()["X-Foo"])
+
+	// act
+	response.Set("X-Count", 3)
+
+	// assert
+	assert.Equal(t, "3", response.Get("X-Count"))
+}
+
+func TestResponse_Append(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	response.Set("Link", "<http://localhost/>")
+
+	// act
+	response.Append("Link", "<http://localhost:3000/>", "<http://localhost:8080/>")
+
+	// assert
+	assert.Equal(t, []string{ "<http://localhost/>", "<http://localhost:3000/>", "<http://localhost:8080/>" }, response.Res.Header()["Link"])
+}
+
+func TestResponse_Vary(t *testing.T) {
+	var response *Response
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+
+	// act
+	response.Vary("Accept-Encoding")
+	response.Vary("accept-encoding", "Origin")
+
+	// assert
+	assert.Equal(t, "Accept-Encoding, Origin", response.Get("Vary"))
+
+	// act
+	response.Vary("*")
+	response.Vary("Accept")
+
+	// assert
+	assert.Equal(t, "*", response.Get("Vary"))
+}
+
+func TestResponse_GetHeaders(t *testing.T) {
+	var response *Response
+	var headers http.Header
+
+	// arrange
+	response = NewResponse()
+	response.Res = httptest.NewRecorder()
+	response.Set("X-Foo", "bar")
+
+	// act
+	headers = response.GetHeaders()
+	headers.Set("X-Foo", "baz")
+
+	// assert
+	assert.Equal(t, "bar", response.Get("X-Foo"))
+}
+
+func TestResponse_HeaderSent(t *testing.T) {
+	var response *Response
+	var rec *httptest.ResponseRecorder
+
+	// arrange
+	rec = httptest.NewRecorder()
+	response = NewResponse()
+	response.Res = newResponseWriter(rec)
+	response.Set("Content-Type", "text/event-stream")
+	response.SetStatus(http.StatusOK)
+
+	// assert
+	assert.False(t, response.HeaderSent())
+	assert.True(t, response.Writable())
+
+	// act
+	response.FlushHeaders()
+
+	// assert
+	assert.True(t, response.HeaderSent())
+	assert.True(t, response.Writable())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, ErrHeaderSent, response.Set("X-Foo", "bar"))
+	assert.Equal(t, ErrHeaderSent, response.SetStatus(http.StatusNotFound))
+	assert.Equal(t, ErrHeaderSent, response.SetBody("hello"))
+	assert.Equal(t, ErrHeaderSent, response.Append("X-Foo", "bar"))
+	assert.Equal(t, ErrHeaderSent, response.Remove("Content-Type"))
+	assert.Equal(t, ErrHeaderSent, response.Vary("Origin"))
+	assert.Equal(t, ErrHeaderSent, response.SetType("json"))
+	assert.Equal(t, ErrHeaderSent, response.SetLength(5))
+	assert.Equal(t, ErrHeaderSent, response.SetEtag("abc"))
+	assert.Equal(t, ErrHeaderSent, response.SetLastModified(time.Now()))
+	assert.Equal(t, ErrHeaderSent, response.Attachment("report.pdf", nil))
+	assert.Equal(t, ErrHeaderSent, response.Redirect("/login"))
+	assert.False(t, response.Has("X-Foo"))
+	assert.False(t, response.Has("Vary"))
+	assert.False(t, response.Has("Content-Disposition"))
+	assert.False(t, response.Has("Location"))
+	assert.Equal(t, "text/event-stream", response.Get("Content-Type"))
+	assert.Equal(t, http.StatusOK, response.GetStatus())
+	assert.Equal(t, []byte(nil), response.GetBody())
+
+	// act
+	response.finished = true
+
+	// assert
+	assert.False(t, response.Writable())
+}
@@ -0,0 +1,113 @@
+package gokoa
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ErrHeaderSent is returned when modifying the HTTP response status
+// code, header or body after the HTTP response header is sent.
+var ErrHeaderSent = errors.New("gokoa: header already sent")
+
+// A responseWriter wraps a primitive http.ResponseWriter, recording
+// whether the HTTP response header is sent.
+type responseWriter struct {
+	http.ResponseWriter
+
+	// statusCode is the HTTP status code sent to the client, or 0 when
+	// the HTTP response header is NOT sent yet.
+	statusCode int
+
+	// hijacked is true when the connection is taken over by Hijack.
+	hijacked bool
+}
+
+// newResponseWriter returns a new responseWriter wrapping the given
+// http.ResponseWriter.
+func newResponseWriter(res http.ResponseWriter) *responseWriter {
+	return &responseWriter{
+		ResponseWriter: res,
+	}
+}
+
+// headerSent returns true when the HTTP response header is sent.
+func (res *responseWriter) headerSent() bool {
+	return res.statusCode != 0
+}
+
+// WriteHeader sends the HTTP response header with the given status
+// code, which takes effect only at the first call.
+func (res *responseWriter) WriteHeader(statusCode int) {
+	if res.headerSent() {
+		return
+	}
+	res.statusCode = statusCode
+	res.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes the given bytes to the HTTP response body, which sends
+// the HTTP response header with 200 OK if it is NOT sent yet.
+func (res *responseWriter) Write(bytes []byte) (int, error) {
+	if !res.headerSent() {
+		res.WriteHeader(http.StatusOK)
+	}
+	return res.ResponseWriter.Write(bytes)
+}
+
+// ReadFrom copies the given reader to the HTTP response body, which
+// sends the HTTP response header with 200 OK if it is NOT sent yet.
+//
+// ReadFrom is forwarded to the primitive http.ResponseWriter if it
+// supports it, e.g. to send a file with sendfile.
+func (res *responseWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if !res.headerSent() {
+		res.WriteHeader(http.StatusOK)
+	}
+	if readerFrom, ok := res.ResponseWriter.(io.ReaderFrom); ok {
+		return readerFrom.ReadFrom(reader)
+	}
+	return io.Copy(writerOnly{res.ResponseWriter}, reader)
+}
+
+// Push initiates an HTTP/2 server push, if the primitive
+// http.ResponseWriter supports it.
+func (res *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := res.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Flush sends any buffered data to the client, if the primitive
+// http.ResponseWriter supports it.
+func (res *responseWriter) Flush() {
+	if flusher, ok := res.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, if the primitive
+// http.ResponseWriter supports it, after which the HTTP response
+// header is regarded as sent.
+func (res *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := res.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gokoa: hijacking is NOT supported")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		res.statusCode = http.StatusSwitchingProtocols
+		res.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// writerOnly hides any method other than Write of an io.Writer, so
+// that io.Copy does NOT call ReadFrom recursively.
+type writerOnly struct {
+	io.Writer
+}
@@ -0,0 +1,132 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter_WriteHeader(t *testing.T) {
+	var rec *httptest.ResponseRecorder
+	var res *responseWriter
+
+	// arrange
+	rec = httptest.NewRecorder()
+	res = newResponseWriter(rec)
+
+	// assert
+	assert.False(t, res.headerSent())
+
+	// act
+	res.WriteHeader(http.StatusAccepted)
+	res.WriteHeader(http.StatusInternalServerError)
+
+	// assert
+	assert.True(t, res.headerSent())
+	assert.Equal(t, http.StatusAccepted, res.statusCode)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
+
+func TestResponseWriter_Write(t *testing.T) {
+	var rec *httptest.ResponseRecorder
+	var res *responseWriter
+
+	// arrange
+	rec = httptest.NewRecorder()
+	res = newResponseWriter(rec)
+
+	// act
+	res.Write([]byte("hello"))
+	res.Flush()
+
+	// assert
+	assert.True(t, res.headerSent())
+	assert.Equal(t, http.StatusOK, res.statusCode)
+	assert.Equal(t, "hello", rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
+
+func TestResponseWriter_Hijack(t *testing.T) {
+	var res *responseWriter
+	var err error
+
+	// arrange
+	res = newResponseWriter(httptest.NewRecorder())
+
+	// act
+	_, _, err = res.Hijack()
+
+	// assert
+	assert.NotNil(t, err)
+	assert.False(t, res.headerSent())
+}
+
+// readerFromRecorder is a ResponseRecorder implementing io.ReaderFrom
+// and http.Pusher, which records calls of them.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+	pushed   []string
+}
+
+func (rec *readerFromRecorder) ReadFrom(reader io.Reader) (int64, error) {
+	rec.readFrom = true
+	return io.Copy(rec.ResponseRecorder, reader)
+}
+
+func (rec *readerFromRecorder) Push(target string, opts *http.PushOptions) error {
+	rec.pushed = append(rec.pushed, target)
+	return nil
+}
+
+func TestResponseWriter_ReadFrom(t *testing.T) {
+	var rec *readerFromRecorder
+	var res *responseWriter
+	var n int64
+	var err error
+
+	// arrange
+	rec = &readerFromRecorder{ ResponseRecorder: httptest.NewRecorder() }
+	res = newResponseWriter(rec)
+
+	// act
+	n, err = io.Copy(res, struct{ io.Reader }{ strings.NewReader("hello") })
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.True(t, rec.readFrom)
+	assert.True(t, res.headerSent())
+	assert.Equal(t, "hello", rec.Body.String())
+
+	// arrange
+	plain := httptest.NewRecorder()
+	res = newResponseWriter(plain)
+
+	// act
+	n, err = res.ReadFrom(strings.NewReader("hello"))
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, http.StatusOK, plain.Code)
+	assert.Equal(t, "hello", plain.Body.String())
+}
+
+func TestResponseWriter_Push(t *testing.T) {
+	var rec *readerFromRecorder
+
+	// arrange
+	rec = &readerFromRecorder{ ResponseRecorder: httptest.NewRecorder() }
+
+	// act
+	err := newResponseWriter(rec).Push("/app.js", nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ "/app.js" }, rec.pushed)
+	assert.Equal(t, http.ErrNotSupported, newResponseWriter(httptest.NewRecorder()).Push("/app.js", nil))
+}
@@ -0,0 +1,369 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// A Layer represents a single route registered into a Router.
+//
+// A Layer matches request paths against a pattern compiled from Path,
+// and captures named parameters from the matched path.
+type Layer struct {
+	// Path is the path pattern of the Layer, e.g. "/users/:id".
+	Path string
+
+	// Name is the name of the Layer, which is used to look up the
+	// Layer when generating URLs, default to an empty string.
+	Name string
+
+	// Methods are the HTTP methods handled by the Layer. An empty
+	// Methods means that the Layer handles requests of any method.
+	Methods []string
+
+	// stack is the middleware chain executed when the Layer matches.
+	stack []gokoa.Middleware
+
+	// paramNames are the names of parameters in the path pattern, in
+	// the order of capturing groups in regexp.
+	paramNames []string
+
+	// regexp is the regular expression compiled from the path pattern.
+	regexp *regexp.Regexp
+
+	// sensitive, strict and end are options used to compile the path
+	// pattern, kept for recompiling after prefixing.
+	sensitive bool
+	strict    bool
+	end       bool
+}
+
+// tokenRegexp matches parameters and wildcards in a path pattern.
+//
+// The submatches are respectively the prefix of a parameter, the name
+// of a parameter, the custom pattern of a parameter, the modifier of
+// a parameter and an unnamed wildcard. A custom pattern may contain
+// escaped characters and groups nested one level deep, e.g. "((a|b))".
+var tokenRegexp = regexp.MustCompile(`([/.]?)(?::(\w+)(\((?:\\.|[^\\()]|\((?:\\.|[^\\()])*\))*\))?([?*+])?|(\*))`)
+
+// newLayer returns a new Layer initialized with the given path
+// pattern, methods and middlewares.
+//
+// sensitive enables case sensitive matching, strict disables the
+// optional trailing slash, and end requires the whole path to be
+// matched, otherwise a prefix ending at a segment boundary is enough.
+//
+// newLayer panics when the path pattern can NOT be compiled.
+func newLayer(path string, methods []string, stack []gokoa.Middleware, sensitive bool, strict bool, end bool) *Layer {
+	layer := &Layer{
+		Path:      path,
+		stack:     stack,
+		sensitive: sensitive,
+		strict:    strict,
+		end:       end,
+	}
+
+	for _, method := range methods {
+		method = strings.ToUpper(method)
+		if method == "GET" && !contains(layer.Methods, "HEAD") {
+			layer.Methods = append([]string{"HEAD"}, layer.Methods...)
+		}
+		layer.Methods = append(layer.Methods, method)
+	}
+
+	layer.compile()
+
+	return layer
+}
+
+// setPrefix prepends the given prefix to the path pattern of the
+// Layer, and recompiles it.
+func (layer *Layer) setPrefix(prefix string) {
+	if layer.Path != "/" || layer.strict {
+		layer.Path = prefix + layer.Path
+	} else {
+		layer.Path = prefix
+	}
+	layer.paramNames = nil
+	layer.compile()
+}
+
+// clone returns a copy of the Layer, which can be prefixed without
+// affecting the original one.
+func (layer *Layer) clone() *Layer {
+	cloned := *layer
+	cloned.Methods = append([]string(nil), layer.Methods...)
+	cloned.stack = append([]gokoa.Middleware(nil), layer.stack...)
+	cloned.paramNames = append([]string(nil), layer.paramNames...)
+	return &cloned
+}
+
+// compile compiles the path pattern of the Layer into a regular
+// expression, according to the options of the Layer.
+//
+// The following forms of parameters are supported:
+//
+//	/:name      a single segment
+//	/:name?     an optional segment
+//	/:name+     one or more segments
+//	/:name*     zero or more segments
+//	/:name(\d+) a single segment matching the custom pattern
+//	/*          any characters, captured as an unnamed parameter
+//
+// Unnamed parameters are named by their indexes, starting from "0".
+// Groups in custom patterns are compiled as non-capturing, so that
+// they do NOT shift submatches of the following parameters.
+func (layer *Layer) compile() {
+	var builder strings.Builder
+
+	if !layer.sensitive {
+		builder.WriteString("(?i)")
+	}
+	builder.WriteString("^")
+
+	path := layer.Path
+	if !layer.strict && len(path) > 1 && strings.HasSuffix(path, "/") {
+		path = path[:len(path)-1]
+	}
+
+	unnamed := 0
+	last := 0
+	for _, indexes := range tokenRegexp.FindAllStringSubmatchIndex(path, -1) {
+		builder.WriteString(regexp.QuoteMeta(path[last:indexes[0]]))
+		last = indexes[1]
+
+		submatch := func(i int) string {
+			if indexes[2*i] < 0 {
+				return ""
+			}
+			return path[indexes[2*i]:indexes[2*i+1]]
+		}
+		prefix := regexp.QuoteMeta(submatch(1))
+		name := submatch(2)
+		pattern := submatch(3)
+		modifier := submatch(4)
+
+		if submatch(5) != "" {
+			layer.paramNames = append(layer.paramNames, strconv.Itoa(unnamed))
+			unnamed++
+			builder.WriteString(prefix + "(.*)")
+			continue
+		}
+
+		layer.paramNames = append(layer.paramNames, name)
+		if pattern == "" {
+			pattern = "[^/]+?"
+		} else {
+			pattern = nonCapturing(pattern[1 : len(pattern)-1])
+		}
+
+		switch modifier {
+		case "?":
+			builder.WriteString("(?:" + prefix + "(" + pattern + "))?")
+		case "+":
+			builder.WriteString(prefix + "((?:" + pattern + ")(?:" + prefix + "(?:" + pattern + "))*)")
+		case "*":
+			builder.WriteString("(?:" + prefix + "((?:" + pattern + ")(?:" + prefix + "(?:" + pattern + "))*))?")
+		default:
+			builder.WriteString(prefix + "(" + pattern + ")")
+		}
+	}
+	builder.WriteString(regexp.QuoteMeta(path[last:]))
+
+	if !layer.strict {
+		builder.WriteString("/?")
+	}
+	if layer.end {
+		builder.WriteString("$")
+	} else {
+		builder.WriteString("(?:/|$)")
+	}
+
+	layer.regexp = regexp.MustCompile(builder.String())
+}
+
+// nonCapturing returns the given regular expression with capturing
+// groups, including named ones, rewritten into non-capturing groups.
+func nonCapturing(pattern string) string {
+	var builder strings.Builder
+
+	class := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			builder.WriteString(pattern[i : i+2])
+			i++
+			continue
+		case class:
+			if c == ']' {
+				class = false
+			}
+		case c == '[':
+			class = true
+			// a leading "]" or "^]" in a character class is a literal
+			if strings.HasPrefix(pattern[i+1:], "^]") {
+				builder.WriteString("[^]")
+				i += 2
+				continue
+			}
+			if strings.HasPrefix(pattern[i+1:], "]") {
+				builder.WriteString("[]")
+				i++
+				continue
+			}
+		case c == '(':
+			rest := pattern[i+1:]
+			if !strings.HasPrefix(rest, "?") {
+				builder.WriteString("(?:")
+				continue
+			}
+			if strings.HasPrefix(rest, "?P<") || strings.HasPrefix(rest, "?<") {
+				if end := strings.IndexByte(rest, '>'); end >= 0 {
+					builder.WriteString("(?:")
+					i += end + 1
+					continue
+				}
+			}
+		}
+		builder.WriteByte(c)
+	}
+
+	return builder.String()
+}
+
+// Match returns true when the given path matches the Layer.
+func (layer *Layer) Match(path string) bool {
+	return layer.regexp.MatchString(path)
+}
+
+// Params returns named parameters captured from the given path,
+// merged into a copy of the given existing parameters.
+//
+// Captured values are unescaped, and unmatched optional parameters
+// are omitted.
+func (layer *Layer) Params(path string, existing map[string]string) map[string]string {
+	params := make(map[string]string, len(existing)+len(layer.paramNames))
+	for name, value := range existing {
+		params[name] = value
+	}
+
+	indexes := layer.regexp.FindStringSubmatchIndex(path)
+	if indexes == nil {
+		return params
+	}
+
+	for i, name := range layer.paramNames {
+		start, end := indexes[2*i+2], indexes[2*i+3]
+		if start < 0 {
+			continue
+		}
+		value := path[start:end]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		params[name] = value
+	}
+
+	return params
+}
+
+// URL returns a URL generated from the path pattern of the Layer, by
+// replacing parameters with the given values and appending the given
+// query string.
+//
+// Values are escaped, except that slashes in values of parameters
+// matching multiple segments are kept.
+//
+// URL returns an error when a required parameter is missing, or a
+// value does NOT match the custom pattern of its parameter.
+func (layer *Layer) URL(params map[string]string, query url.Values) (string, error) {
+	var builder strings.Builder
+
+	path := layer.Path
+	unnamed := 0
+	last := 0
+	for _, submatches := range tokenRegexp.FindAllStringSubmatchIndex(path, -1) {
+		builder.WriteString(path[last:submatches[0]])
+		last = submatches[1]
+
+		submatch := func(i int) string {
+			if submatches[2*i] < 0 {
+				return ""
+			}
+			return path[submatches[2*i]:submatches[2*i+1]]
+		}
+		prefix := submatch(1)
+		name := submatch(2)
+		pattern := submatch(3)
+		modifier := submatch(4)
+
+		if submatch(5) != "" {
+			name = strconv.Itoa(unnamed)
+			unnamed++
+			modifier = "*"
+		}
+
+		value, ok := params[name]
+		if !ok || value == "" {
+			if modifier == "?" || modifier == "*" {
+				continue
+			}
+			return "", fmt.Errorf("missing parameter %q", name)
+		}
+
+		if pattern != "" {
+			matched, err := regexp.MatchString("^(?:"+pattern[1:len(pattern)-1]+")$", value)
+			if err != nil {
+				return "", err
+			}
+			if !matched {
+				return "", fmt.Errorf("parameter %q does not match %s", name, pattern)
+			}
+		}
+
+		builder.WriteString(prefix)
+		if modifier == "+" || modifier == "*" {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			builder.WriteString(strings.Join(segments, "/"))
+		} else {
+			builder.WriteString(url.PathEscape(value))
+		}
+	}
+	builder.WriteString(path[last:])
+
+	if builder.Len() == 0 {
+		builder.WriteString("/")
+	}
+
+	if len(query) != 0 {
+		builder.WriteString("?")
+		builder.WriteString(query.Encode())
+	}
+
+	return builder.String(), nil
+}
+
+// errRouteNotFound is returned when no route is named as the given
+// name, which is gokoa.ErrRouteNotFound, so that it is recognized by
+// Context.URL.
+var errRouteNotFound = gokoa.ErrRouteNotFound
+
+// contains returns true when the given string exists in the given
+// slice.
+func contains(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestLayer_Match(t *testing.T) {
+	var layer *Layer
+
+	// arrange
+	layer = newLayer("/users/:id", []string{"GET"}, nil, false, false, true)
+
+	// assert
+	assert.Equal(t, []string{"HEAD", "GET"}, layer.Methods)
+	assert.True(t, layer.Match("/users/1"))
+	assert.True(t, layer.Match("/users/1/"))
+	assert.True(t, layer.Match("/USERS/1"))
+	assert.False(t, layer.Match("/users"))
+	assert.False(t, layer.Match("/users/1/posts"))
+
+	// arrange
+	layer = newLayer("/users/:id", []string{"GET"}, nil, true, true, true)
+
+	// assert
+	assert.True(t, layer.Match("/users/1"))
+	assert.False(t, layer.Match("/users/1/"))
+	assert.False(t, layer.Match("/USERS/1"))
+
+	// arrange
+	layer = newLayer("/admin", nil, nil, false, false, false)
+
+	// assert
+	assert.True(t, layer.Match("/admin"))
+	assert.True(t, layer.Match("/admin/users"))
+	assert.False(t, layer.Match("/administrator"))
+}
+
+func TestLayer_Params(t *testing.T) {
+	var layer *Layer
+
+	// arrange
+	layer = newLayer("/users/:id/posts/:post", nil, nil, false, false, true)
+
+	// assert
+	assert.Equal(t, map[string]string{"id": "1", "post": "hello world"}, layer.Params("/users/1/posts/hello%20world", nil))
+	assert.Equal(t, map[string]string{"id": "1", "post": "2", "lang": "en"}, layer.Params("/users/1/posts/2", map[string]string{"lang": "en"}))
+
+	// arrange
+	layer = newLayer("/users/:id?", nil, nil, false, false, true)
+
+	// assert
+	assert.True(t, layer.Match("/users"))
+	assert.Equal(t, map[string]string{}, layer.Params("/users", nil))
+	assert.Equal(t, map[string]string{"id": "1"}, layer.Params("/users/1", nil))
+
+	// arrange
+	layer = newLayer("/files/:path*", nil, nil, false, false, true)
+
+	// assert
+	assert.Equal(t, map[string]string{}, layer.Params("/files", nil))
+	assert.Equal(t, map[string]string{"path": "a/b/c"}, layer.Params("/files/a/b/c", nil))
+
+	// arrange
+	layer = newLayer("/files/:path+", nil, nil, false, false, true)
+
+	// assert
+	assert.False(t, layer.Match("/files"))
+	assert.Equal(t, map[string]string{"path": "a/b"}, layer.Params("/files/a/b", nil))
+
+	// arrange
+	layer = newLayer("/static/*", nil, nil, false, false, true)
+
+	// assert
+	assert.Equal(t, map[string]string{"0": "css/main.css"}, layer.Params("/static/css/main.css", nil))
+
+	// arrange
+	layer = newLayer(`/orders/:id(\d+)`, nil, nil, false, false, true)
+
+	// assert
+	assert.True(t, layer.Match("/orders/42"))
+	assert.False(t, layer.Match("/orders/abc"))
+	assert.Equal(t, map[string]string{"id": "42"}, layer.Params("/orders/42", nil))
+
+	// arrange
+	layer = newLayer("/:id((a|b))/:name", nil, nil, false, false, true)
+
+	// assert
+	assert.True(t, layer.Match("/a/x"))
+	assert.False(t, layer.Match("/c/x"))
+	assert.Equal(t, map[string]string{"id": "a", "name": "x"}, layer.Params("/a/x", nil))
+
+	// arrange
+	layer = newLayer(`/:id((?P<v>\d+)\(x\))/:name`, nil, nil, false, false, true)
+
+	// assert
+	assert.Equal(t, map[string]string{"id": "1(x)", "name": "x"}, layer.Params("/1(x)/x", nil))
+}
+
+func TestNonCapturing(t *testing.T) {
+	// assert
+	assert.Equal(t, `(?:a|b)`, nonCapturing(`(a|b)`))
+	assert.Equal(t, `(?:\d+)-(?:x)(?i:y)`, nonCapturing(`(?P<n>\d+)-(?<m>x)(?i:y)`))
+	assert.Equal(t, `\((?:a)\)[(]`, nonCapturing(`\((a)\)[(]`))
+	assert.Equal(t, `[]()](?:a)[^]()]`, nonCapturing(`[]()](a)[^]()]`))
+}
+
+func TestLayer_URL(t *testing.T) {
+	var layer *Layer
+	var u string
+	var err error
+
+	// arrange
+	layer = newLayer("/users/:id/posts/:post?", nil, nil, false, false, true)
+
+	// act
+	u, err = layer.URL(map[string]string{"id": "a b", "post": "1"}, url.Values{"tab": {"comments"}})
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/a%20b/posts/1?tab=comments", u)
+
+	// act
+	u, err = layer.URL(map[string]string{"id": "1"}, nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/1/posts", u)
+
+	// act
+	_, err = layer.URL(nil, nil)
+
+	// assert
+	assert.NotNil(t, err)
+
+	// arrange
+	layer = newLayer("/files/:path*", nil, nil, false, false, true)
+
+	// act
+	u, err = layer.URL(map[string]string{"path": "a/b c"}, nil)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/files/a/b%20c", u)
+
+	// arrange
+	layer = newLayer(`/orders/:id(\d+)`, nil, nil, false, false, true)
+
+	// act
+	_, err = layer.URL(map[string]string{"id": "abc"}, nil)
+
+	// assert
+	assert.NotNil(t, err)
+}
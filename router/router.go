@@ -0,0 +1,452 @@
+// Package router provides a Koa styled router for gokoa, which
+// dispatches HTTP requests to middlewares by path and method.
+//
+//	r := router.NewRouter(nil)
+//
+//	r.Get("/users/:id", func(ctx *gokoa.Context, next func() error) error {
+//		ctx.SetBody("user " + ctx.Params["id"])
+//		return nil
+//	})
+//
+//	app.Use(r.Routes())
+//	app.Use(r.AllowedMethods())
+//
+// Routers can be nested under prefixes, with middlewares only executed
+// for their own routes:
+//
+//	admin := router.NewRouter(nil)
+//	admin.Use(auth)
+//	admin.Get("/dashboard", dashboard)
+//
+//	r.Mount("/admin", admin)
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// A RouterConfig is a container which stores settings for configuring
+// a Router.
+//
+// The RouterConfig is organized as key-value pairs, where the value is
+// of limited type.
+type RouterConfig map[string]interface{}
+
+// A Router dispatches HTTP requests to middlewares registered with
+// path patterns and HTTP methods.
+type Router struct {
+	// stack contains layers registered into the Router, in the order
+	// of registration.
+	stack []*Layer
+
+	// methods are the HTTP methods implemented by the Router, default
+	// to HEAD, OPTIONS, GET, PUT, PATCH, POST and DELETE.
+	methods []string
+
+	// sensitive is equal to true when path patterns are matched case
+	// sensitively, default to false.
+	sensitive bool
+
+	// strict is equal to true when trailing slashes in path patterns
+	// are NOT optional, default to false.
+	strict bool
+
+	// prefix is prepended to path patterns of all layers in the
+	// Router, default to an empty string.
+	prefix string
+}
+
+// A matchResult contains layers matching a request.
+type matchResult struct {
+	// path contains layers whose paths match the request path.
+	path []*Layer
+
+	// pathAndMethod contains layers whose paths and methods both match
+	// the request.
+	pathAndMethod []*Layer
+
+	// route is equal to true when there is at least one layer with
+	// methods in pathAndMethod.
+	route bool
+}
+
+// matchedKey is the key in Context.State, under which layers matching
+// the request path are stored for AllowedMethods.
+const matchedKey = "router.matched"
+
+// NewRouter returns a new Router initialized with the given config.
+//
+// The config can be nil, which causes the Router to use default
+// configuration settings.
+//
+// Values in key-value pairs must be in the valid type, otherwise
+// NewRouter will panic.
+func NewRouter(config RouterConfig) *Router {
+	router := &Router{}
+
+	if config == nil {
+		config = make(RouterConfig)
+	}
+
+	if methods, ok := config["methods"]; ok {
+		for _, method := range methods.([]string) {
+			router.methods = append(router.methods, strings.ToUpper(method))
+		}
+	} else {
+		router.methods = []string{
+			http.MethodHead,
+			http.MethodOptions,
+			http.MethodGet,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodPost,
+			http.MethodDelete,
+		}
+	}
+
+	if sensitive, ok := config["sensitive"]; ok {
+		router.sensitive = sensitive.(bool)
+	}
+
+	if strict, ok := config["strict"]; ok {
+		router.strict = strict.(bool)
+	}
+
+	if prefix, ok := config["prefix"]; ok {
+		router.prefix = strings.TrimSuffix(prefix.(string), "/")
+	}
+
+	return router
+}
+
+// Register registers the given middlewares into the Router, which
+// will be executed in order for requests matching the given path
+// pattern and one of the given methods.
+//
+// Register returns the newly created Layer.
+func (router *Router) Register(path string, methods []string, middlewares ...gokoa.Middleware) *Layer {
+	return router.register(path, methods, middlewares, true)
+}
+
+// register registers a new Layer into the Router, and prefixes it
+// with the prefix of the Router.
+func (router *Router) register(path string, methods []string, middlewares []gokoa.Middleware, end bool) *Layer {
+	layer := newLayer(path, methods, middlewares, router.sensitive, router.strict, end)
+	if router.prefix != "" {
+		layer.setPrefix(router.prefix)
+	}
+	router.stack = append(router.stack, layer)
+	return layer
+}
+
+// Use registers the given middlewares into the Router, which will be
+// executed before middlewares of any matched route registered after
+// them.
+//
+// Unlike Application.Use, the given middlewares are NOT executed for
+// requests matching no route in the Router.
+//
+// Use returns the Router itself, which enables chained function call
+// instead of function calls in multiple lines.
+func (router *Router) Use(middlewares ...gokoa.Middleware) *Router {
+	router.register("", nil, middlewares, false)
+	return router
+}
+
+// UsePath is similar to Use, except that the given middlewares are
+// only executed for routes whose paths start with the given path
+// pattern.
+func (router *Router) UsePath(path string, middlewares ...gokoa.Middleware) *Router {
+	router.register(path, nil, middlewares, false)
+	return router
+}
+
+// Mount nests the given Router under the given prefix, so that its
+// routes and middlewares registered by Use are matched against paths
+// starting with the prefix.
+//
+// Layers of the given Router are copied into the Router, therefore
+// routes registered into the given Router after mounting are NOT
+// visible in the Router.
+func (router *Router) Mount(prefix string, nested *Router) *Router {
+	prefix = strings.TrimSuffix(prefix, "/")
+	for _, layer := range nested.stack {
+		cloned := layer.clone()
+		if prefix != "" {
+			cloned.setPrefix(prefix)
+		}
+		if router.prefix != "" {
+			cloned.setPrefix(router.prefix)
+		}
+		router.stack = append(router.stack, cloned)
+	}
+	return router
+}
+
+// Prefix sets the prefix of the Router, and prepends it to path
+// patterns of all layers registered before.
+func (router *Router) Prefix(prefix string) *Router {
+	prefix = strings.TrimSuffix(prefix, "/")
+	router.prefix = prefix
+	for _, layer := range router.stack {
+		layer.setPrefix(prefix)
+	}
+	return router
+}
+
+// Name assigns the given name to the route registered latest, which
+// can later be used to look up the route and generate URLs.
+//
+// Name panics when there is no route registered.
+func (router *Router) Name(name string) *Router {
+	if len(router.stack) == 0 {
+		panic("router: no route to be named")
+	}
+	router.stack[len(router.stack)-1].Name = name
+	return router
+}
+
+// Route returns the route named as the given name, or nil when there
+// is no such route.
+func (router *Router) Route(name string) *Layer {
+	for _, layer := range router.stack {
+		if layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// URL returns a URL generated from the route named as the given name,
+// with the given parameters and query string.
+//
+//	r.Get("/users/:id", show).Name("user.show")
+//	r.URL("user.show", map[string]string{"id": "1"}, url.Values{"tab": {"posts"}})
+//	// "/users/1?tab=posts"
+//
+// URL returns an error when there is no such route, or the URL can
+// NOT be generated from the route.
+func (router *Router) URL(name string, params map[string]string, query url.Values) (string, error) {
+	layer := router.Route(name)
+	if layer == nil {
+		return "", fmt.Errorf("%w: %s", errRouteNotFound, name)
+	}
+	return layer.URL(params, query)
+}
+
+// Redirect registers a route, which redirects requests matching the
+// source path pattern of any method to the destination with the
+// given status code.
+//
+// The destination can be either a name of a route, or a path or URL
+// used as it is. When it is a name, the URL is generated with
+// parameters captured from the source path.
+//
+// The status code can be 0, which means 301 Moved Permanently.
+func (router *Router) Redirect(source string, destination string, statusCode int) *Router {
+	if statusCode == 0 {
+		statusCode = http.StatusMovedPermanently
+	}
+
+	return router.All(source, func(ctx *gokoa.Context, next func() error) error {
+		location := destination
+		if router.Route(destination) != nil {
+			var err error
+			location, err = router.URL(destination, ctx.Params, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		ctx.SetStatus(statusCode)
+		return ctx.Redirect(location)
+	})
+}
+
+// Get registers the given middlewares for GET and HEAD requests
+// matching the given path pattern.
+//
+// Get returns the Router itself, which enables chained function call
+// instead of function calls in multiple lines. The same applies to
+// the other registering methods.
+func (router *Router) Get(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodGet}, middlewares...)
+	return router
+}
+
+// Head registers the given middlewares for HEAD requests matching the
+// given path pattern.
+func (router *Router) Head(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodHead}, middlewares...)
+	return router
+}
+
+// Options registers the given middlewares for OPTIONS requests
+// matching the given path pattern.
+func (router *Router) Options(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodOptions}, middlewares...)
+	return router
+}
+
+// Post registers the given middlewares for POST requests matching the
+// given path pattern.
+func (router *Router) Post(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodPost}, middlewares...)
+	return router
+}
+
+// Put registers the given middlewares for PUT requests matching the
+// given path pattern.
+func (router *Router) Put(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodPut}, middlewares...)
+	return router
+}
+
+// Patch registers the given middlewares for PATCH requests matching
+// the given path pattern.
+func (router *Router) Patch(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodPatch}, middlewares...)
+	return router
+}
+
+// Delete registers the given middlewares for DELETE requests matching
+// the given path pattern.
+func (router *Router) Delete(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, []string{http.MethodDelete}, middlewares...)
+	return router
+}
+
+// All registers the given middlewares for requests of all methods
+// implemented by the Router, matching the given path pattern.
+func (router *Router) All(path string, middlewares ...gokoa.Middleware) *Router {
+	router.Register(path, router.methods, middlewares...)
+	return router
+}
+
+// match returns layers in the Router matching the given path and
+// method.
+func (router *Router) match(path string, method string) *matchResult {
+	result := &matchResult{}
+
+	for _, layer := range router.stack {
+		if !layer.Match(path) {
+			continue
+		}
+		result.path = append(result.path, layer)
+
+		if len(layer.Methods) == 0 || contains(layer.Methods, method) {
+			result.pathAndMethod = append(result.pathAndMethod, layer)
+			if len(layer.Methods) != 0 {
+				result.route = true
+			}
+		}
+	}
+
+	return result
+}
+
+// Routes returns a Middleware, which dispatches requests to
+// middlewares registered into the Router.
+//
+// When more than one layer matches the request, their middlewares are
+// chained in the order of registration. Parameters captured by each
+// layer are merged into Context.Params before its middlewares run.
+//
+// When no layer matches the request, the returned Middleware simply
+// calls next.
+//
+// The Router is registered into the Context by UseURLGenerator, so that
+// a middleware can redirect to a named route without a reference to
+// the Router, e.g.
+//
+//	return ctx.RedirectTo("user.show", map[string]string{"id": "1"}, nil)
+func (router *Router) Routes() gokoa.Middleware {
+	return func(ctx *gokoa.Context, next func() error) error {
+		ctx.UseURLGenerator(router)
+
+		path := ctx.Request.Req.URL.EscapedPath()
+		result := router.match(path, ctx.Request.GetMethod())
+
+		matched, _ := ctx.State[matchedKey].([]*Layer)
+		ctx.State[matchedKey] = append(matched, result.path...)
+
+		if !result.route {
+			return next()
+		}
+
+		var chain []gokoa.Middleware
+		for _, layer := range result.pathAndMethod {
+			layer := layer
+			chain = append(chain, func(ctx *gokoa.Context, next func() error) error {
+				ctx.Params = layer.Params(path, ctx.Params)
+				return next()
+			})
+			chain = append(chain, layer.stack...)
+		}
+
+		return gokoa.Compose(chain...)(ctx, next)
+	}
+}
+
+// AllowedMethods returns a Middleware, which responds to requests NOT
+// handled by any middleware according to methods of layers matching
+// the request path.
+//
+// The returned Middleware responds with:
+//
+//	501 Not Implemented    when the method is NOT implemented by the Router
+//	200 OK                 to OPTIONS requests
+//	405 Method Not Allowed when the method is NOT allowed for the path
+//
+// All responses above carry an Allow header listing the methods
+// allowed for the path.
+//
+// AllowedMethods must be used together with Routes.
+func (router *Router) AllowedMethods() gokoa.Middleware {
+	return func(ctx *gokoa.Context, next func() error) error {
+		if err := next(); err != nil {
+			return err
+		}
+
+		if ctx.GetStatus() != http.StatusNotFound {
+			return nil
+		}
+
+		matched, ok := ctx.State[matchedKey].([]*Layer)
+		if !ok {
+			return nil
+		}
+
+		var allowed []string
+		for _, layer := range matched {
+			for _, method := range layer.Methods {
+				if !contains(allowed, method) {
+					allowed = append(allowed, method)
+				}
+			}
+		}
+
+		method := ctx.Request.GetMethod()
+		if !contains(router.methods, method) {
+			if len(allowed) != 0 {
+				ctx.Response.Set("Allow", strings.Join(allowed, ", "))
+			}
+			ctx.SetStatus(http.StatusNotImplemented)
+		} else if len(allowed) != 0 {
+			if method == http.MethodOptions {
+				ctx.SetBody("")
+				ctx.Response.Set("Allow", strings.Join(allowed, ", "))
+			} else if !contains(allowed, method) {
+				ctx.Response.Set("Allow", strings.Join(allowed, ", "))
+				ctx.SetStatus(http.StatusMethodNotAllowed)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,439 @@
+package router
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// serve handles the given request with an Application using the given
+// middlewares, and returns the recorded response and its body.
+func serve(t *testing.T, req *http.Request, middlewares ...gokoa.Middleware) (*http.Response, []byte) {
+	app := gokoa.NewApplication(nil)
+	for _, middleware := range middlewares {
+		app.Use(middleware)
+	}
+
+	rec := httptest.NewRecorder()
+	app.Callback()(rec, req)
+
+	res := rec.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	return res, body
+}
+
+func TestNewRouter_WithoutConfig(t *testing.T) {
+	var router *Router
+
+	// act
+	router = NewRouter(nil)
+
+	// assert
+	assert.Equal(t, []string{"HEAD", "OPTIONS", "GET", "PUT", "PATCH", "POST", "DELETE"}, router.methods)
+	assert.Equal(t, false, router.sensitive)
+	assert.Equal(t, false, router.strict)
+}
+
+func TestNewRouter_WithConfig(t *testing.T) {
+	var router *Router
+
+	// act
+	router = NewRouter(RouterConfig{
+		"methods":   []string{"get", "post"},
+		"sensitive": true,
+		"strict":    true,
+	})
+
+	// assert
+	assert.Equal(t, []string{"GET", "POST"}, router.methods)
+	assert.Equal(t, true, router.sensitive)
+	assert.Equal(t, true, router.strict)
+}
+
+func TestRouter_Routes(t *testing.T) {
+	var router *Router
+	var res *http.Response
+	var body []byte
+
+	// arrange
+	router = NewRouter(nil)
+	router.
+		Get("/users/:id", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("get user " + ctx.Params["id"])
+			return nil
+		}).
+		Post("/users", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetStatus(http.StatusCreated)
+			return nil
+		})
+
+	// act
+	res, body = serve(t, httptest.NewRequest(http.MethodGet, "/users/42", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []byte("get user 42"), body)
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodPost, "/users", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodGet, "/posts", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestRouter_Routes_All(t *testing.T) {
+	var router *Router
+	var body []byte
+
+	// arrange
+	router = NewRouter(nil)
+	router.
+		All("/users/:id", func(ctx *gokoa.Context, next func() error) error {
+			ctx.State["user"] = ctx.Params["id"]
+			return next()
+		}).
+		Delete("/users/:id", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("delete user " + ctx.State["user"].(string))
+			return nil
+		})
+
+	// act
+	_, body = serve(t, httptest.NewRequest(http.MethodDelete, "/users/42", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, []byte("delete user 42"), body)
+}
+
+func TestRouter_Routes_CallsNext(t *testing.T) {
+	var router *Router
+	var body []byte
+
+	// arrange
+	router = NewRouter(nil)
+	router.Get("/", func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody("router")
+		return next()
+	})
+
+	// act
+	_, body = serve(t, httptest.NewRequest(http.MethodGet, "/", nil), router.Routes(), func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody(string(ctx.GetBody()) + " next")
+		return nil
+	})
+
+	// assert
+	assert.Equal(t, []byte("router next"), body)
+}
+
+func TestRouter_AllowedMethods(t *testing.T) {
+	var router *Router
+	var res *http.Response
+
+	// arrange
+	router = NewRouter(nil)
+	router.
+		Get("/users", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("users")
+			return nil
+		}).
+		Post("/users", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetStatus(http.StatusCreated)
+			return nil
+		})
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodPut, "/users", nil), router.Routes(), router.AllowedMethods())
+
+	// assert
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	assert.Equal(t, "HEAD, GET, POST", res.Header.Get("Allow"))
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodOptions, "/users", nil), router.Routes(), router.AllowedMethods())
+
+	// assert
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "HEAD, GET, POST", res.Header.Get("Allow"))
+
+	// act
+	res, _ = serve(t, httptest.NewRequest("PROPFIND", "/users", nil), router.Routes(), router.AllowedMethods())
+
+	// assert
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+	assert.Equal(t, "HEAD, GET, POST", res.Header.Get("Allow"))
+
+	// act
+	res, _ = serve(t, httptest.NewRequest("PROPFIND", "/unknown", nil), router.Routes(), router.AllowedMethods())
+
+	// assert
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+	_, ok := res.Header["Allow"]
+	assert.False(t, ok)
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodPut, "/posts", nil), router.Routes(), router.AllowedMethods())
+
+	// assert
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "", res.Header.Get("Allow"))
+}
+
+func TestRouter_Register_MultipleMiddlewares(t *testing.T) {
+	var router *Router
+	var body []byte
+
+	// arrange
+	router = NewRouter(nil)
+	router.Get("/",
+		func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("first")
+			return next()
+		},
+		func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody(string(ctx.GetBody()) + " second")
+			return nil
+		},
+		func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody(string(ctx.GetBody()) + " third")
+			return nil
+		},
+	)
+
+	// act
+	_, body = serve(t, httptest.NewRequest(http.MethodGet, "/", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, []byte("first second"), body)
+}
+
+func TestRouter_Use(t *testing.T) {
+	var router *Router
+	var calledTimes int
+	var res *http.Response
+	var body []byte
+
+	// arrange
+	calledTimes = 0
+	router = NewRouter(nil)
+	router.
+		Use(func(ctx *gokoa.Context, next func() error) error {
+			calledTimes++
+			return next()
+		}).
+		UsePath("/admin", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetStatus(http.StatusUnauthorized)
+			return nil
+		}).
+		Get("/", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("home")
+			return nil
+		}).
+		Get("/admin/dashboard", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("dashboard")
+			return nil
+		})
+
+	// act
+	_, body = serve(t, httptest.NewRequest(http.MethodGet, "/", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, 1, calledTimes)
+	assert.Equal(t, []byte("home"), body)
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodGet, "/admin/dashboard", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, 2, calledTimes)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// act
+	serve(t, httptest.NewRequest(http.MethodGet, "/missing", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, 2, calledTimes)
+}
+
+func TestRouter_Mount(t *testing.T) {
+	var router *Router
+	var users *Router
+	var posts *Router
+	var res *http.Response
+	var body []byte
+
+	// arrange
+	posts = NewRouter(nil)
+	posts.Get("/:post", func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody("user " + ctx.Params["user"] + " post " + ctx.Params["post"])
+		return nil
+	})
+	users = NewRouter(nil)
+	users.
+		Use(func(ctx *gokoa.Context, next func() error) error {
+			ctx.Response.Set("X-Users", "true")
+			return next()
+		}).
+		Get("/", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("users")
+			return nil
+		}).
+		Mount("/:user/posts", posts)
+	router = NewRouter(RouterConfig{"prefix": "/api/"})
+	router.
+		Get("/", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("api")
+			return nil
+		}).
+		Mount("/users", users)
+
+	// act
+	res, body = serve(t, httptest.NewRequest(http.MethodGet, "/api/users/1/posts/2", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, "true", res.Header.Get("X-Users"))
+	assert.Equal(t, []byte("user 1 post 2"), body)
+
+	// act
+	res, body = serve(t, httptest.NewRequest(http.MethodGet, "/api/users", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, "true", res.Header.Get("X-Users"))
+	assert.Equal(t, []byte("users"), body)
+
+	// act
+	res, body = serve(t, httptest.NewRequest(http.MethodGet, "/api", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, "", res.Header.Get("X-Users"))
+	assert.Equal(t, []byte("api"), body)
+}
+
+func TestRouter_Prefix(t *testing.T) {
+	var router *Router
+	var res *http.Response
+	var body []byte
+
+	// arrange
+	router = NewRouter(nil)
+	router.Get("/users", func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody("users")
+		return nil
+	})
+
+	// act
+	router.Prefix("/v1")
+	_, body = serve(t, httptest.NewRequest(http.MethodGet, "/v1/users", nil), router.Routes())
+	res, _ = serve(t, httptest.NewRequest(http.MethodGet, "/users", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, []byte("users"), body)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestRouter_URL(t *testing.T) {
+	var router *Router
+	var users *Router
+	var u string
+	var err error
+
+	// arrange
+	users = NewRouter(nil)
+	users.Get("/:id", func(ctx *gokoa.Context, next func() error) error {
+		return nil
+	}).Name("user.show")
+	router = NewRouter(nil)
+	router.Mount("/users", users)
+
+	// act
+	u, err = router.URL("user.show", map[string]string{"id": "1"}, url.Values{"tab": {"posts"}})
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/1?tab=posts", u)
+
+	// act
+	_, err = router.URL("user.missing", nil, nil)
+
+	// assert
+	assert.NotNil(t, err)
+}
+
+func TestRouter_Redirect(t *testing.T) {
+	var router *Router
+	var res *http.Response
+
+	// arrange
+	router = NewRouter(nil)
+	router.
+		Get("/users/:id", func(ctx *gokoa.Context, next func() error) error {
+			return nil
+		}).
+		Name("user.show").
+		Redirect("/members/:id", "user.show", 0).
+		Redirect("/login", "https://example.com/login", http.StatusFound)
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodGet, "/members/42", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, http.StatusMovedPermanently, res.StatusCode)
+	assert.Equal(t, "/users/42", res.Header.Get("Location"))
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodPost, "/login", nil), router.Routes())
+
+	// assert
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+	assert.Equal(t, "https://example.com/login", res.Header.Get("Location"))
+}
+
+func TestContext_RedirectTo(t *testing.T) {
+	var users *Router
+	var auth *Router
+	var res *http.Response
+	var err error
+
+	// arrange
+	users = NewRouter(nil)
+	users.
+		Get("/users/:id", func(ctx *gokoa.Context, next func() error) error {
+			return nil
+		}).
+		Name("user.show")
+	auth = NewRouter(nil)
+	auth.
+		Post("/login", func(ctx *gokoa.Context, next func() error) error {
+			return ctx.RedirectTo("user.show", map[string]string{"id": "42"}, url.Values{"welcome": {"1"}})
+		}).
+		Post("/logout", func(ctx *gokoa.Context, next func() error) error {
+			err = ctx.RedirectTo("home", nil, nil)
+			return nil
+		})
+
+	// act
+	res, _ = serve(t, httptest.NewRequest(http.MethodPost, "/login", nil), users.Routes(), auth.Routes())
+
+	// assert
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+	assert.Equal(t, "/users/42?welcome=1", res.Header.Get("Location"))
+
+	// act
+	serve(t, httptest.NewRequest(http.MethodPost, "/logout", nil), users.Routes(), auth.Routes())
+
+	// assert
+	assert.True(t, errors.Is(err, gokoa.ErrRouteNotFound))
+}
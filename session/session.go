@@ -0,0 +1,385 @@
+// Package session provides a middleware for gokoa, which keeps session
+// values either in a signed cookie or in a server-side Store.
+//
+//	app := gokoa.NewApplication(gokoa.ApplicationConfig{
+//		"keys": []string{"secret"},
+//	})
+//	app.Use(session.New(nil))
+//
+//	app.Use(func(ctx *gokoa.Context, next func() error) error {
+//		sess := session.FromContext(ctx)
+//		views, _ := sess.Get("views").(float64)
+//		sess.Set("views", views+1)
+//		...
+//	})
+//
+// Session values are serialized into JSON, so that they are read back
+// as types decoded by encoding/json, e.g. float64 for numbers.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// A Config is a container which stores settings for configuring the
+// session middleware.
+//
+// The Config is organized as key-value pairs, where the value is of
+// limited type.
+type Config map[string]interface{}
+
+// stateKey is the key in Context.State, with which the Session is
+// stored.
+const stateKey = "session"
+
+// expireKey is the reserved key in persisted session values, with
+// which the expiration time in Unix milliseconds is stored.
+const expireKey = "_expire"
+
+// A Session contains values of a single client across HTTP requests.
+type Session struct {
+	// id is the session ID when the session is kept in a Store.
+	id string
+
+	// values are session values.
+	values map[string]interface{}
+
+	// snapshot is values in JSON when the session is loaded, which is
+	// used to determine whether the session is changed.
+	snapshot string
+
+	// expire is the time when the session is expired, or zero time
+	// when the session is new.
+	expire time.Time
+
+	// regenerated is true when Regenerate is called.
+	regenerated bool
+
+	// destroyed is true when Destroy is called.
+	destroyed bool
+}
+
+// FromContext returns the Session of the given Context, or nil when the
+// session middleware is NOT used.
+func FromContext(ctx *gokoa.Context) *Session {
+	session, _ := ctx.State[stateKey].(*Session)
+	return session
+}
+
+// New returns a Middleware, which loads the Session from the cookie
+// before calling next, and saves the Session after next returns.
+//
+// The Set-Cookie header is written only when the Session is changed,
+// regenerated or destroyed, or when it should be renewed.
+//
+// The config can be nil, which causes the session middleware to use
+// default configuration settings:
+//
+//	key      string               cookie name, default to "gokoa.sess"
+//	maxAge   time.Duration        lifetime of a session, default to 24 hours
+//	rolling  bool                 renew the session on every request, default to false
+//	renew    bool                 renew the session when it is nearly expired, default to false
+//	store    Store                server-side store, default to nil, which means the cookie itself
+//	cookie   gokoa.CookieOptions  options of the cookie, default to nil
+//
+// When the session is kept in the cookie, the cookie is signed with
+// keys of the Application unless "signed" is false in the cookie
+// options, in which case the client can forge it.
+//
+// Values in key-value pairs must be in the valid type, otherwise New
+// will panic.
+func New(config Config) gokoa.Middleware {
+	if config == nil {
+		config = make(Config)
+	}
+
+	key := "gokoa.sess"
+	if k, ok := config["key"]; ok {
+		key = k.(string)
+	}
+
+	maxAge := 24 * time.Hour
+	if m, ok := config["maxAge"]; ok {
+		maxAge = m.(time.Duration)
+	}
+
+	rolling := false
+	if r, ok := config["rolling"]; ok {
+		rolling = r.(bool)
+	}
+
+	renew := false
+	if r, ok := config["renew"]; ok {
+		renew = r.(bool)
+	}
+
+	var store Store
+	if s, ok := config["store"]; ok {
+		store = s.(Store)
+	}
+
+	cookie := gokoa.CookieOptions{}
+	if c, ok := config["cookie"]; ok {
+		for k, v := range c.(gokoa.CookieOptions) {
+			cookie[k] = v
+		}
+	}
+	if _, ok := cookie["signed"]; !ok && store == nil {
+		cookie["signed"] = true
+	}
+
+	m := &middleware{
+		key:     key,
+		maxAge:  maxAge,
+		rolling: rolling,
+		renew:   renew,
+		store:   store,
+		cookie:  cookie,
+	}
+
+	return func(ctx *gokoa.Context, next func() error) error {
+		session, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+		ctx.State[stateKey] = session
+
+		err = next()
+		if commitErr := m.commit(ctx, session); err == nil {
+			err = commitErr
+		}
+		return err
+	}
+}
+
+// A middleware loads and saves sessions.
+type middleware struct {
+	key     string
+	maxAge  time.Duration
+	rolling bool
+	renew   bool
+	store   Store
+	cookie  gokoa.CookieOptions
+}
+
+// load returns the Session of the client, or a new Session when the
+// client has none, or it is expired or invalid.
+func (m *middleware) load(ctx *gokoa.Context) (*Session, error) {
+	value, ok := ctx.Cookies.Get(m.key, m.cookie)
+	if !ok || value == "" {
+		return newSession(), nil
+	}
+
+	var values map[string]interface{}
+	if m.store != nil {
+		var err error
+		values, err = m.store.Get(value)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		values = decode(value)
+	}
+	if values == nil {
+		return newSession(), nil
+	}
+
+	var expire int64
+	switch e := values[expireKey].(type) {
+	case float64:
+		expire = int64(e)
+	case int64:
+		expire = e
+	}
+	delete(values, expireKey)
+	session := &Session{
+		values: values,
+		expire: time.Unix(0, expire*int64(time.Millisecond)),
+	}
+	if !time.Now().Before(session.expire) {
+		return newSession(), nil
+	}
+	if m.store != nil {
+		session.id = value
+	}
+	session.snapshot = session.marshal()
+	return session, nil
+}
+
+// commit saves the given Session, and writes the Set-Cookie header
+// when it is necessary.
+func (m *middleware) commit(ctx *gokoa.Context, session *Session) error {
+	if ctx.Response.HeaderSent() {
+		return nil
+	}
+
+	if session.destroyed || (len(session.values) == 0 && !session.IsNew()) {
+		if session.id != "" && m.store != nil {
+			if err := m.store.Destroy(session.id); err != nil {
+				return err
+			}
+		}
+		if session.IsNew() {
+			return nil
+		}
+		return ctx.Cookies.Set(m.key, "", m.cookie)
+	}
+
+	changed := session.regenerated || session.marshal() != session.snapshot
+	if session.IsNew() && len(session.values) == 0 {
+		return nil
+	}
+	if !changed && !m.rolling && !(m.renew && time.Until(session.expire) < m.maxAge/2) {
+		return nil
+	}
+
+	expire := time.Now().Add(m.maxAge)
+	values := make(map[string]interface{}, len(session.values)+1)
+	for k, v := range session.values {
+		values[k] = v
+	}
+	values[expireKey] = expire.UnixNano() / int64(time.Millisecond)
+
+	var value string
+	if m.store != nil {
+		if session.regenerated && session.id != "" {
+			if err := m.store.Destroy(session.id); err != nil {
+				return err
+			}
+			session.id = ""
+		}
+		if session.id == "" {
+			id, err := generateID()
+			if err != nil {
+				return err
+			}
+			session.id = id
+		}
+		if err := m.store.Set(session.id, values, m.maxAge); err != nil {
+			return err
+		}
+		value = session.id
+	} else {
+		var err error
+		if value, err = encode(values); err != nil {
+			return err
+		}
+	}
+
+	opts := gokoa.CookieOptions{"maxAge": m.maxAge}
+	for k, v := range m.cookie {
+		opts[k] = v
+	}
+	return ctx.Cookies.Set(m.key, value, opts)
+}
+
+// newSession returns a new empty Session.
+func newSession() *Session {
+	session := &Session{
+		values: make(map[string]interface{}),
+	}
+	session.snapshot = session.marshal()
+	return session
+}
+
+// IsNew returns true when the Session is created by this request.
+func (session *Session) IsNew() bool {
+	return session.expire.IsZero()
+}
+
+// ID returns the session ID when the Session is kept in a Store, or an
+// empty string otherwise, or when the Session is NOT saved yet.
+func (session *Session) ID() string {
+	return session.id
+}
+
+// Get returns the value corresponding to the given key, or nil when
+// there is no such value.
+func (session *Session) Get(key string) interface{} {
+	return session.values[key]
+}
+
+// Set assigns the given value to the given key.
+func (session *Session) Set(key string, value interface{}) {
+	session.values[key] = value
+	session.destroyed = false
+}
+
+// Delete deletes the value corresponding to the given key.
+func (session *Session) Delete(key string) {
+	delete(session.values, key)
+}
+
+// Values returns a copy of all session values.
+func (session *Session) Values() map[string]interface{} {
+	values := make(map[string]interface{}, len(session.values))
+	for k, v := range session.values {
+		values[k] = v
+	}
+	return values
+}
+
+// Regenerate makes the Session saved with a new session ID, keeping
+// values, while the old one is destroyed, which should be called after
+// the user signs in to prevent session fixation.
+//
+// When the Session is kept in the cookie, Regenerate just makes the
+// cookie rewritten.
+func (session *Session) Regenerate() {
+	session.regenerated = true
+}
+
+// Destroy removes all values, and makes the Session destroyed, e.g.
+// after the user signs out.
+func (session *Session) Destroy() {
+	session.values = make(map[string]interface{})
+	session.destroyed = true
+}
+
+// marshal returns values in JSON, or an empty string when they can NOT
+// be marshaled.
+func (session *Session) marshal() string {
+	bytes, err := json.Marshal(session.values)
+	if err != nil {
+		return ""
+	}
+	return string(bytes)
+}
+
+// encode encodes the given values into a cookie value.
+func encode(values map[string]interface{}) (string, error) {
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// decode decodes the given cookie value into values, or nil when it is
+// invalid.
+func decode(value string) map[string]interface{} {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(bytes, &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// generateID returns a new random session ID.
+func generateID() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,222 @@
+package session
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newApplication returns a new Application with the session middleware
+// and the given handler.
+func newApplication(config Config, handler func(session *Session)) *gokoa.Application {
+	app := gokoa.NewApplication(gokoa.ApplicationConfig{"keys": []string{"secret"}})
+	app.Use(New(config)).Use(func(ctx *gokoa.Context, next func() error) error {
+		handler(FromContext(ctx))
+		ctx.SetStatus(http.StatusOK)
+		return nil
+	})
+	return app
+}
+
+// request sends a request with the given cookies to the given
+// Application, and returns cookies set by the response.
+func request(app *gokoa.Application, cookies []*http.Cookie) []*http.Cookie {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	app.Callback()(rec, req)
+	return rec.Result().Cookies()
+}
+
+func TestNew_Cookie(t *testing.T) {
+	var app *gokoa.Application
+	var cookies []*http.Cookie
+	var views float64
+	var isNew bool
+
+	// arrange
+	app = newApplication(nil, func(session *Session) {
+		isNew = session.IsNew()
+		views, _ = session.Get("views").(float64)
+		session.Set("views", views+1)
+	})
+
+	// act
+	cookies = request(app, nil)
+
+	// assert
+	assert.True(t, isNew)
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, "gokoa.sess", cookies[0].Name)
+	assert.Equal(t, "gokoa.sess.sig", cookies[1].Name)
+	assert.Equal(t, 86400, cookies[0].MaxAge)
+
+	// act
+	request(app, cookies)
+
+	// assert
+	assert.False(t, isNew)
+	assert.Equal(t, float64(1), views)
+
+	// arrange
+	cookies[0].Value = encodeForTest(t, map[string]interface{}{"views": 100})
+
+	// act
+	request(app, cookies)
+
+	// assert
+	assert.True(t, isNew)
+	assert.Equal(t, float64(0), views)
+}
+
+func TestNew_Unchanged(t *testing.T) {
+	var app *gokoa.Application
+	var cookies []*http.Cookie
+
+	// arrange
+	app = newApplication(nil, func(session *Session) {
+		if session.IsNew() {
+			session.Set("user", "gokoa")
+		}
+	})
+	cookies = request(app, nil)
+
+	// act
+	cookies = request(app, cookies)
+
+	// assert
+	assert.Len(t, cookies, 0)
+
+	// arrange
+	app = newApplication(nil, func(session *Session) {})
+
+	// act
+	cookies = request(app, nil)
+
+	// assert
+	assert.Len(t, cookies, 0)
+}
+
+func TestNew_Rolling(t *testing.T) {
+	var app *gokoa.Application
+	var cookies []*http.Cookie
+
+	// arrange
+	app = newApplication(Config{"rolling": true, "maxAge": time.Hour}, func(session *Session) {
+		session.Set("user", "gokoa")
+	})
+	cookies = request(app, nil)
+
+	// act
+	cookies = request(app, cookies)
+
+	// assert
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+}
+
+func TestNew_Renew(t *testing.T) {
+	var app *gokoa.Application
+	var cookies []*http.Cookie
+
+	// arrange
+	app = newApplication(Config{"renew": true, "maxAge": time.Second}, func(session *Session) {
+		session.Set("user", "gokoa")
+	})
+	cookies = request(app, nil)
+
+	// act
+	cookies = request(app, cookies)
+
+	// assert
+	assert.Len(t, cookies, 0)
+
+	// arrange
+	cookies = request(app, nil)
+	time.Sleep(600 * time.Millisecond)
+
+	// act
+	cookies = request(app, cookies)
+
+	// assert
+	assert.Len(t, cookies, 2)
+}
+
+func TestNew_Store(t *testing.T) {
+	var store *MemoryStore
+	var app *gokoa.Application
+	var cookies []*http.Cookie
+	var regenerate bool
+	var destroy bool
+	var user interface{}
+	var id string
+
+	// arrange
+	store = NewMemoryStore()
+	app = newApplication(Config{"store": store}, func(session *Session) {
+		user = session.Get("user")
+		if session.IsNew() {
+			session.Set("user", "gokoa")
+		}
+		if regenerate {
+			session.Regenerate()
+		}
+		if destroy {
+			session.Destroy()
+		}
+		id = session.ID()
+	})
+
+	// act
+	cookies = request(app, nil)
+
+	// assert
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, "", id)
+	values, _ := store.Get(cookies[0].Value)
+	assert.Equal(t, "gokoa", values["user"])
+
+	// arrange
+	regenerate = true
+
+	// act
+	regenerated := request(app, cookies)
+
+	// assert
+	assert.Equal(t, "gokoa", user)
+	assert.Equal(t, cookies[0].Value, id)
+	assert.Len(t, regenerated, 2)
+	assert.NotEqual(t, cookies[0].Value, regenerated[0].Value)
+	values, _ = store.Get(cookies[0].Value)
+	assert.Nil(t, values)
+	values, _ = store.Get(regenerated[0].Value)
+	assert.Equal(t, "gokoa", values["user"])
+
+	// arrange
+	regenerate = false
+	destroy = true
+
+	// act
+	cookies = request(app, regenerated)
+
+	// assert
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, "", cookies[0].Value)
+	assert.True(t, strings.HasPrefix(cookies[0].Raw, "gokoa.sess=; Path=/; Expires=Thu, 01 Jan 1970"))
+	values, _ = store.Get(regenerated[0].Value)
+	assert.Nil(t, values)
+}
+
+// encodeForTest encodes the given values into a cookie value.
+func encodeForTest(t *testing.T, values map[string]interface{}) string {
+	values[expireKey] = time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	value, err := encode(values)
+	assert.Nil(t, err)
+	return value
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// A Store keeps session values on the server side, while only session
+// IDs are kept in cookies, e.g. in memory, Redis or a database.
+//
+// Values are passed as they are, so that a Store must copy or
+// serialize them if it keeps them.
+type Store interface {
+	// Get returns values of the session with the given ID, or nil when
+	// the session does NOT exist or is expired.
+	Get(id string) (map[string]interface{}, error)
+
+	// Set saves values of the session with the given ID, which will be
+	// expired after the given duration.
+	Set(id string, values map[string]interface{}, maxAge time.Duration) error
+
+	// Destroy removes the session with the given ID.
+	Destroy(id string) error
+}
+
+// A MemoryStore is a Store keeping sessions in memory, which is
+// suitable for development and single-process deployment.
+//
+// Values are serialized into JSON, so that they are read back as types
+// decoded by encoding/json, e.g. float64 for numbers.
+type MemoryStore struct {
+	mutex    sync.Mutex
+	sessions map[string]memorySession
+
+	// sweptAt is the time when expired sessions are removed last time.
+	sweptAt time.Time
+}
+
+// sweepInterval is the minimum interval between two sweeps of expired
+// sessions in a MemoryStore.
+const sweepInterval = time.Minute
+
+// A memorySession is a session kept in a MemoryStore.
+type memorySession struct {
+	values []byte
+	expire time.Time
+}
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]memorySession),
+		sweptAt:  time.Now(),
+	}
+}
+
+// Get returns values of the session with the given ID, or nil when the
+// session does NOT exist or is expired.
+func (store *MemoryStore) Get(id string) (map[string]interface{}, error) {
+	store.mutex.Lock()
+	session, ok := store.sessions[id]
+	if ok && !time.Now().Before(session.expire) {
+		delete(store.sessions, id)
+		ok = false
+	}
+	store.mutex.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(session.values, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Set saves values of the session with the given ID, which will be
+// expired after the given duration.
+//
+// Expired sessions are removed at the same time, at most once a minute,
+// while an expired session is removed whenever it is read by Get.
+func (store *MemoryStore) Set(id string, values map[string]interface{}, maxAge time.Duration) error {
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if now.Sub(store.sweptAt) >= sweepInterval {
+		for key, session := range store.sessions {
+			if !now.Before(session.expire) {
+				delete(store.sessions, key)
+			}
+		}
+		store.sweptAt = now
+	}
+	store.sessions[id] = memorySession{
+		values: bytes,
+		expire: now.Add(maxAge),
+	}
+	return nil
+}
+
+// Destroy removes the session with the given ID.
+func (store *MemoryStore) Destroy(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.sessions, id)
+	return nil
+}
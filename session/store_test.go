@@ -0,0 +1,71 @@
+package session
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	var store *MemoryStore
+	var values map[string]interface{}
+	var err error
+
+	// arrange
+	store = NewMemoryStore()
+
+	// act
+	err = store.Set("id", map[string]interface{}{"views": 1}, time.Hour)
+
+	// assert
+	assert.Nil(t, err)
+
+	// act
+	values, err = store.Get("id")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"views": float64(1)}, values)
+
+	// act
+	err = store.Destroy("id")
+	values, _ = store.Get("id")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Nil(t, values)
+}
+
+func TestMemoryStore_Expired(t *testing.T) {
+	var store *MemoryStore
+	var values map[string]interface{}
+
+	// arrange
+	store = NewMemoryStore()
+	store.Set("expired", map[string]interface{}{"views": 1}, -time.Second)
+
+	// act
+	values, _ = store.Get("expired")
+
+	// assert
+	assert.Nil(t, values)
+	assert.Len(t, store.sessions, 0)
+
+	// arrange
+	store.sessions["expired"] = memorySession{expire: time.Now().Add(-time.Second)}
+
+	// act
+	store.Set("id", map[string]interface{}{}, time.Hour)
+
+	// assert
+	assert.Len(t, store.sessions, 2)
+
+	// arrange
+	store.sweptAt = time.Now().Add(-sweepInterval)
+
+	// act
+	store.Set("id", map[string]interface{}{}, time.Hour)
+
+	// assert
+	assert.Len(t, store.sessions, 1)
+}
@@ -0,0 +1,286 @@
+package gokoa
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A FieldError describes a field violating a validation rule.
+type FieldError struct {
+	// Field is the path of the field, e.g. "address.city" or
+	// "items[0].name".
+	Field string `json:"field"`
+
+	// Rule is the name of the violated rule, e.g. "required".
+	Rule string `json:"rule"`
+
+	// Param is the parameter of the violated rule, e.g. "3" for
+	// "min=3".
+	Param string `json:"param,omitempty"`
+
+	// Message is a human readable description of the violation.
+	Message string `json:"message"`
+}
+
+// A ValidationError is returned when values violate validation rules,
+// which will be rendered as 422 Unprocessable Entity with field level
+// details.
+type ValidationError struct {
+	// Fields contains all violations found.
+	Fields []FieldError `json:"errors"`
+}
+
+// Error returns messages of all violations joined by "; ".
+func (err *ValidationError) Error() string {
+	messages := make([]string, len(err.Fields))
+	for i, field := range err.Fields {
+		messages[i] = field.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// regexps caches regular expressions compiled from regex rules.
+var regexps sync.Map
+
+// Validate validates fields of the given struct, or pointer to struct,
+// against rules declared in their "validate" tags, and returns a
+// *ValidationError containing all violations, or nil when there is
+// none.
+//
+// Rules are separated by commas:
+//
+//	required    the field must NOT be the zero value
+//	min=N       numbers must be >= N, strings, slices and maps must
+//	            contain >= N characters or items
+//	max=N       similar to min, but <= N
+//	oneof=A B   the field must be one of the space separated values
+//	email       the string must be an email address
+//	regex=P     the string must match the regular expression, which
+//	            must be the last rule as it may contain commas
+//
+// Rules other than required are skipped for zero values. Nested
+// structs, and slices of them, are validated recursively.
+//
+// Fields are named by their "json", "form", "query" or "param" tags,
+// whichever present first, and by their names otherwise.
+//
+//	type User struct {
+//		Name  string `json:"name" validate:"required,min=3,max=20"`
+//		Email string `json:"email" validate:"required,email"`
+//		Role  string `json:"role" validate:"oneof=admin user"`
+//	}
+func Validate(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []FieldError
+	validateStruct(value, "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// validateStruct validates fields of the given struct value, and
+// appends violations to the given array, with field paths prefixed.
+func validateStruct(value reflect.Value, prefix string, violations *[]FieldError) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		for _, key := range []string{"json", "form", "query", "param"} {
+			if _, ok := field.Tag.Lookup(key); ok {
+				name = fieldName(field, key)
+				break
+			}
+		}
+		if name == "-" {
+			continue
+		}
+		fieldValue := value.Field(i)
+		path := prefix + name
+		if field.Anonymous {
+			path = strings.TrimSuffix(prefix, ".")
+		}
+
+		if tag, ok := field.Tag.Lookup("validate"); ok && tag != "" {
+			validateField(fieldValue, path, tag, violations)
+		}
+
+		validateNested(fieldValue, path, violations)
+	}
+}
+
+// validateNested validates nested structs in the given value, which
+// can be a struct, a pointer to struct, or a slice of them.
+func validateNested(value reflect.Value, path string, violations *[]FieldError) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if _, ok := value.Interface().(interface{ String() string }); ok {
+			return
+		}
+		if path != "" {
+			path += "."
+		}
+		validateStruct(value, path, violations)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateNested(value.Index(i), fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+	}
+}
+
+// validateField validates the given value against rules in the given
+// tag.
+func validateField(value reflect.Value, path string, tag string, violations *[]FieldError) {
+	violate := func(rule string, param string, format string, args ...interface{}) {
+		*violations = append(*violations, FieldError{
+			Field:   path,
+			Rule:    rule,
+			Param:   param,
+			Message: path + " " + fmt.Sprintf(format, args...),
+		})
+	}
+
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	zero := isZero(value)
+
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i != -1 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i != -1 {
+			name, param = rule[:i], rule[i+1:]
+		}
+
+		if name == "required" {
+			if zero {
+				violate(name, "", "is required")
+				return
+			}
+			continue
+		}
+		if zero {
+			continue
+		}
+
+		switch name {
+		case "min", "max":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				panic(fmt.Sprintf("gokoa: invalid parameter of rule %q", rule))
+			}
+			actual, unit := measure(value)
+			if (name == "min" && actual < limit) || (name == "max" && actual > limit) {
+				if name == "min" {
+					violate(name, param, "must be at least %s%s", param, unit)
+				} else {
+					violate(name, param, "must be at most %s%s", param, unit)
+				}
+			}
+		case "oneof":
+			actual := fmt.Sprint(value.Interface())
+			found := false
+			for _, option := range strings.Fields(param) {
+				if option == actual {
+					found = true
+					break
+				}
+			}
+			if !found {
+				violate(name, param, "must be one of [%s]", param)
+			}
+		case "email":
+			address, err := mail.ParseAddress(value.String())
+			if value.Kind() != reflect.String || err != nil || address.Address != value.String() {
+				violate(name, "", "must be a valid email address")
+			}
+		case "regex":
+			compiled, ok := regexps.Load(param)
+			if !ok {
+				compiled = regexp.MustCompile(param)
+				regexps.Store(param, compiled)
+			}
+			if value.Kind() != reflect.String || !compiled.(*regexp.Regexp).MatchString(value.String()) {
+				violate(name, param, "must match %s", param)
+			}
+		default:
+			panic(fmt.Sprintf("gokoa: unknown validation rule %q", name))
+		}
+	}
+}
+
+// measure returns the number compared by min and max rules for the
+// given value, and the unit of it in messages.
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	}
+	return 0, ""
+}
+
+// isZero returns true when the given value is the zero value of its
+// type, or an empty slice or map.
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// fieldName returns the name of the given struct field in the given
+// tag, or the field name when the tag is absent.
+func fieldName(field reflect.StructField, key string) string {
+	if tag, ok := field.Tag.Lookup(key); ok {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
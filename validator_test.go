@@ -0,0 +1,71 @@
+package gokoa
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type user struct {
+	Name      string     `json:"name" validate:"required,min=3,max=5"`
+	Email     string     `json:"email" validate:"email"`
+	Role      string     `json:"role" validate:"oneof=admin user"`
+	Age       int        `json:"age" validate:"min=18"`
+	Tags      []string   `json:"tags" validate:"max=2"`
+	Code      string     `json:"code" validate:"regex=^[a-z]{1,3}$"`
+	Address   address    `json:"address"`
+	Addresses []*address `json:"addresses"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ignored   string     `validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	var err error
+
+	// act
+	err = Validate(&user{
+		Name:      "gokoa",
+		Address:   address{ City: "Shanghai" },
+		Addresses: []*address{ { City: "Beijing" } },
+	})
+
+	// assert
+	assert.Nil(t, err)
+
+	// act
+	err = Validate(user{
+		Name:      "go",
+		Email:     "gokoa",
+		Role:      "root",
+		Age:       3,
+		Tags:      []string{ "a", "b", "c" },
+		Code:      "abcd",
+		Addresses: []*address{ nil, {} },
+	})
+
+	// assert
+	assert.Equal(t, &ValidationError{ Fields: []FieldError{
+		{ Field: "name", Rule: "min", Param: "3", Message: "name must be at least 3 characters" },
+		{ Field: "email", Rule: "email", Message: "email must be a valid email address" },
+		{ Field: "role", Rule: "oneof", Param: "admin user", Message: "role must be one of [admin user]" },
+		{ Field: "age", Rule: "min", Param: "18", Message: "age must be at least 18" },
+		{ Field: "tags", Rule: "max", Param: "2", Message: "tags must be at most 2 items" },
+		{ Field: "code", Rule: "regex", Param: "^[a-z]{1,3}$", Message: "code must match ^[a-z]{1,3}$" },
+		{ Field: "address.city", Rule: "required", Message: "address.city is required" },
+		{ Field: "addresses[1].city", Rule: "required", Message: "addresses[1].city is required" },
+	} }, err)
+	assert.Contains(t, err.Error(), "validation failed: name must be at least 3 characters; ")
+}
+
+func TestValidate_UnknownRule(t *testing.T) {
+	// assert
+	assert.Panics(t, func() {
+		Validate(&struct {
+			Name string `validate:"unknown"`
+		}{ Name: "gokoa" })
+	})
+}
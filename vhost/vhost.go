@@ -0,0 +1,44 @@
+// Package vhost provides a middleware for gokoa, which dispatches
+// HTTP requests to different middlewares by the request hostname.
+//
+//	api := gokoa.NewApplication(nil)
+//	admin := gokoa.NewApplication(nil)
+//
+//	app.Use(vhost.New("api.example.com", api.Middleware()))
+//	app.Use(vhost.New("admin.example.com", admin.Middleware()))
+package vhost
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/xiaojianzhong/gokoa"
+)
+
+// New returns a Middleware, which executes the given middlewares in
+// order for requests whose hostnames match the given pattern, and
+// simply calls next for the others.
+//
+// The pattern is matched case insensitively, where each "*" matches a
+// single label of the hostname, e.g. "*.example.com" matches
+// "api.example.com" but NOT "example.com" or "v1.api.example.com".
+func New(pattern string, middlewares ...gokoa.Middleware) gokoa.Middleware {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return NewRegexp(regexp.MustCompile("(?i)^"+strings.Join(parts, "[^.]+")+"$"), middlewares...)
+}
+
+// NewRegexp is similar to New, except that the hostname is matched
+// against the given regular expression.
+func NewRegexp(pattern *regexp.Regexp, middlewares ...gokoa.Middleware) gokoa.Middleware {
+	middleware := gokoa.Compose(middlewares...)
+
+	return func(ctx *gokoa.Context, next func() error) error {
+		if !pattern.MatchString(ctx.Request.GetHostname()) {
+			return next()
+		}
+		return middleware(ctx, next)
+	}
+}
@@ -0,0 +1,75 @@
+package vhost
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xiaojianzhong/gokoa"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// serve handles a request to the given host with an Application using
+// the given middlewares, and returns the response body.
+func serve(t *testing.T, host string, middlewares ...gokoa.Middleware) []byte {
+	app := gokoa.NewApplication(nil)
+	for _, middleware := range middlewares {
+		app.Use(middleware)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = host
+	app.Callback()(rec, req)
+
+	res := rec.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	return body
+}
+
+func TestNew(t *testing.T) {
+	var api *gokoa.Application
+	var middlewares []gokoa.Middleware
+
+	// arrange
+	api = gokoa.NewApplication(nil)
+	api.Use(func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody("api")
+		return nil
+	})
+	middlewares = []gokoa.Middleware{
+		New("api.example.com", api.Middleware()),
+		New("*.example.com", func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("wildcard " + ctx.Request.GetSubdomains()[0])
+			return nil
+		}),
+		func(ctx *gokoa.Context, next func() error) error {
+			ctx.SetBody("fallback")
+			return nil
+		},
+	}
+
+	// assert
+	assert.Equal(t, []byte("api"), serve(t, "API.example.com:8080", middlewares...))
+	assert.Equal(t, []byte("wildcard admin"), serve(t, "admin.example.com", middlewares...))
+	assert.Equal(t, []byte("fallback"), serve(t, "v1.api.example.com", middlewares...))
+	assert.Equal(t, []byte("fallback"), serve(t, "example.com", middlewares...))
+}
+
+func TestNewRegexp(t *testing.T) {
+	var middleware gokoa.Middleware
+
+	// arrange
+	middleware = NewRegexp(regexp.MustCompile(`^(www\.)?example\.(com|org)$`), func(ctx *gokoa.Context, next func() error) error {
+		ctx.SetBody("matched")
+		return nil
+	})
+
+	// assert
+	assert.Equal(t, []byte("matched"), serve(t, "www.example.org", middleware))
+	assert.Equal(t, []byte("404"), serve(t, "example.net", middleware))
+}